@@ -0,0 +1,455 @@
+package log
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+var _ AdminHTTPHandler = (*adminHTTPHandler)(nil)
+
+// AdminHTTPOptions 是 AdminHTTPHandler 的选项
+type AdminHTTPOptions struct {
+	maxTTL     time.Duration              // 临时覆盖的最长有效期
+	authorizer func(r *http.Request) bool // 请求鉴权函数
+}
+
+// NewAdminHTTPOptions 创建一个默认的 AdminHTTPOptions
+//   - 默认临时覆盖的最长有效期为 24 小时
+//   - 默认未设置请求鉴权函数，此时所有请求均将被拒绝，必须通过 WithAuthorizer 显式设置
+func NewAdminHTTPOptions() *AdminHTTPOptions {
+	return &AdminHTTPOptions{
+		maxTTL: 24 * time.Hour,
+	}
+}
+
+// WithMaxTTL 设置临时覆盖的最长有效期，当 ttl <= 0 时不限制
+func (o *AdminHTTPOptions) WithMaxTTL(ttl time.Duration) *AdminHTTPOptions {
+	o.maxTTL = ttl
+	return o
+}
+
+// WithAuthorizer 设置请求鉴权函数，当其返回 false 时将响应 403
+//   - 该接口能够在运行时变更日志级别等配置，因此未设置鉴权函数时所有请求均将被拒绝
+//   - 当鉴权已由外层的中间件完成时，可以显式设置一个始终返回 true 的函数
+func (o *AdminHTTPOptions) WithAuthorizer(authorizer func(r *http.Request) bool) *AdminHTTPOptions {
+	o.authorizer = authorizer
+	return o
+}
+
+// AdminSettings 是 AdminHTTPHandler 所暴露的配置项，在 PUT 请求中为 nil 的字段将保持不变
+//   - 日志级别使用 slog.Level 的文本形式，例如 "DEBUG"、"info" 或 "WARN+2"
+//   - GroupLevels 中值为 null 的分组将被移除
+//   - TTL 是临时覆盖的有效期，例如 "10m"，到期后所有临时覆盖的配置项将恢复为覆盖前的值
+type AdminSettings struct {
+	Level          *string            `json:"level,omitempty"`
+	GroupLevels    map[string]*string `json:"group_levels,omitempty"`
+	Caller         *bool              `json:"caller,omitempty"`
+	Color          *bool              `json:"color,omitempty"`
+	ErrTrackLevels *[]string          `json:"err_track_levels,omitempty"`
+	TrackBeautify  *bool              `json:"track_beautify,omitempty"`
+	TTL            string             `json:"ttl,omitempty"`
+	ExpiresAt      *time.Time         `json:"expires_at,omitempty"` // 临时覆盖的到期时间，仅在响应中出现
+}
+
+// AdminHTTPHandler 是一个用于在运行时查看及变更 LoggerConfiguration 的 http.Handler
+//   - GET /：获取所有已注册配置的配置项
+//   - GET /{name}：获取指定配置的配置项
+//   - PUT /{name}：变更指定配置的配置项，请求体为 AdminSettings，当包含 ttl 时为临时覆盖
+//   - DELETE /{name}/override：立即恢复指定配置的临时覆盖
+//
+// 当挂载于子路径时，应当配合 http.StripPrefix 使用
+//
+// 注意：该处理器必须通过 AdminHTTPOptions.WithAuthorizer 设置鉴权函数，否则所有请求均将以 403 拒绝
+type AdminHTTPHandler interface {
+	http.Handler
+
+	// Register 注册一个名为 name 的配置，相同名称的配置将被替换
+	Register(name string, configuration LoggerConfiguration) AdminHTTPHandler
+
+	// Unregister 取消注册名为 name 的配置，它的临时覆盖将被立即恢复
+	Unregister(name string)
+
+	// Close 立即恢复所有临时覆盖
+	Close()
+}
+
+// NewAdminHTTPHandler 创建一个 AdminHTTPHandler，当 options 为 nil 时将使用默认选项
+func NewAdminHTTPHandler(options *AdminHTTPOptions) AdminHTTPHandler {
+	if options == nil {
+		options = NewAdminHTTPOptions()
+	}
+	h := &adminHTTPHandler{
+		options: options,
+		entries: make(map[string]*adminEntry),
+		mux:     http.NewServeMux(),
+		now:     time.Now,
+	}
+	h.mux.HandleFunc("GET /{$}", h.list)
+	h.mux.HandleFunc("GET /{name}", h.get)
+	h.mux.HandleFunc("PUT /{name}", h.put)
+	h.mux.HandleFunc("DELETE /{name}/override", h.revert)
+	return h
+}
+
+type adminEntry struct {
+	configuration LoggerConfiguration
+	baseline      LoggerConfiguration // 临时覆盖前的配置项，当不存在临时覆盖时为 nil
+	expiresAt     time.Time
+	timer         *time.Timer
+}
+
+type adminHTTPHandler struct {
+	rw      sync.Mutex
+	options *AdminHTTPOptions
+	entries map[string]*adminEntry
+	mux     *http.ServeMux
+	now     func() time.Time // 判断临时覆盖是否到期所使用的时钟
+}
+
+func (h *adminHTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.options.authorizer == nil {
+		writeAdminError(w, http.StatusForbidden, errors.New("forbidden: no authorizer configured"))
+		return
+	}
+	if !h.options.authorizer(r) {
+		writeAdminError(w, http.StatusForbidden, errors.New("forbidden"))
+		return
+	}
+	h.mux.ServeHTTP(w, r)
+}
+
+// expire 恢复所有已到期的临时覆盖，它在每次请求时被调用，以免依赖于定时器的触发时机
+func (h *adminHTTPHandler) expire() {
+	now := h.now()
+	for _, entry := range h.entries {
+		if entry.baseline != nil && !now.Before(entry.expiresAt) {
+			entry.restore()
+		}
+	}
+}
+
+func (h *adminHTTPHandler) Register(name string, configuration LoggerConfiguration) AdminHTTPHandler {
+	h.Unregister(name)
+	h.rw.Lock()
+	defer h.rw.Unlock()
+	h.entries[name] = &adminEntry{configuration: configuration}
+	return h
+}
+
+func (h *adminHTTPHandler) Unregister(name string) {
+	h.rw.Lock()
+	defer h.rw.Unlock()
+	if entry, exist := h.entries[name]; exist {
+		entry.restore()
+		delete(h.entries, name)
+	}
+}
+
+func (h *adminHTTPHandler) Close() {
+	h.rw.Lock()
+	defer h.rw.Unlock()
+	for _, entry := range h.entries {
+		entry.restore()
+	}
+}
+
+func (h *adminHTTPHandler) list(w http.ResponseWriter, r *http.Request) {
+	h.rw.Lock()
+	defer h.rw.Unlock()
+	h.expire()
+	settings := make(map[string]AdminSettings, len(h.entries))
+	for name, entry := range h.entries {
+		settings[name] = entry.settings()
+	}
+	writeAdminJSON(w, http.StatusOK, settings)
+}
+
+func (h *adminHTTPHandler) get(w http.ResponseWriter, r *http.Request) {
+	h.rw.Lock()
+	defer h.rw.Unlock()
+	h.expire()
+	entry, exist := h.entries[r.PathValue("name")]
+	if !exist {
+		writeAdminError(w, http.StatusNotFound, fmt.Errorf("configuration %q not found", r.PathValue("name")))
+		return
+	}
+	writeAdminJSON(w, http.StatusOK, entry.settings())
+}
+
+func (h *adminHTTPHandler) put(w http.ResponseWriter, r *http.Request) {
+	var settings AdminSettings
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&settings); err != nil {
+		writeAdminError(w, http.StatusBadRequest, err)
+		return
+	}
+	patch, err := parseAdminSettings(settings)
+	if err != nil {
+		writeAdminError(w, http.StatusBadRequest, err)
+		return
+	}
+	var ttl time.Duration
+	if settings.TTL != "" {
+		if ttl, err = time.ParseDuration(settings.TTL); err != nil || ttl <= 0 {
+			writeAdminError(w, http.StatusBadRequest, fmt.Errorf("invalid ttl %q", settings.TTL))
+			return
+		}
+		if h.options.maxTTL > 0 && ttl > h.options.maxTTL {
+			writeAdminError(w, http.StatusBadRequest, fmt.Errorf("ttl %s exceeds the maximum %s", ttl, h.options.maxTTL))
+			return
+		}
+	}
+
+	h.rw.Lock()
+	defer h.rw.Unlock()
+	h.expire()
+	name := r.PathValue("name")
+	entry, exist := h.entries[name]
+	if !exist {
+		writeAdminError(w, http.StatusNotFound, fmt.Errorf("configuration %q not found", name))
+		return
+	}
+
+	// 变更首先应用于副本，随后整体替换，以免并发的日志记录观察到部分更新的配置
+	staged := GetConfigBuilder().Build()
+	copyAdminSettings(staged, entry.configuration)
+	patch.apply(staged)
+
+	if ttl > 0 {
+		if entry.baseline == nil {
+			entry.baseline = GetConfigBuilder().Build()
+			copyAdminSettings(entry.baseline, entry.configuration)
+		}
+		if entry.timer != nil {
+			entry.timer.Stop()
+		}
+		entry.expiresAt = h.now().Add(ttl)
+		entry.timer = time.AfterFunc(ttl, func() {
+			h.rw.Lock()
+			defer h.rw.Unlock()
+			if h.entries[name] == entry && entry.baseline != nil && !h.now().Before(entry.expiresAt) {
+				entry.restore()
+			}
+		})
+	} else if entry.baseline != nil {
+		// 永久变更同样作用于覆盖前的配置项，以免在临时覆盖到期后被恢复
+		patch.apply(entry.baseline)
+	}
+	replaceAdminSettings(entry.configuration, staged)
+	writeAdminJSON(w, http.StatusOK, entry.settings())
+}
+
+func (h *adminHTTPHandler) revert(w http.ResponseWriter, r *http.Request) {
+	h.rw.Lock()
+	defer h.rw.Unlock()
+	h.expire()
+	entry, exist := h.entries[r.PathValue("name")]
+	if !exist {
+		writeAdminError(w, http.StatusNotFound, fmt.Errorf("configuration %q not found", r.PathValue("name")))
+		return
+	}
+	entry.restore()
+	writeAdminJSON(w, http.StatusOK, entry.settings())
+}
+
+// restore 恢复临时覆盖前的配置项
+func (e *adminEntry) restore() {
+	if e.timer != nil {
+		e.timer.Stop()
+		e.timer = nil
+	}
+	if e.baseline != nil {
+		replaceAdminSettings(e.configuration, e.baseline)
+		e.baseline = nil
+	}
+}
+
+func (e *adminEntry) settings() AdminSettings {
+	config := e.configuration
+	level := config.FetchLeveler().Level().String()
+	caller, color, beautify := config.FetchCaller(), config.FetchEnableColor(), config.FetchTrackBeautify()
+	settings := AdminSettings{
+		Level:         &level,
+		GroupLevels:   make(map[string]*string),
+		Caller:        &caller,
+		Color:         &color,
+		TrackBeautify: &beautify,
+	}
+	for pattern, leveler := range config.FetchGroupLevels() {
+		str := leveler.Level().String()
+		settings.GroupLevels[pattern] = &str
+	}
+	errTrackLevels := make([]string, 0)
+	for _, level := range config.FetchErrTrackLevels() {
+		errTrackLevels = append(errTrackLevels, level.String())
+	}
+	settings.ErrTrackLevels = &errTrackLevels
+	if e.baseline != nil {
+		expiresAt := e.expiresAt
+		settings.ExpiresAt = &expiresAt
+	}
+	return settings
+}
+
+// adminPatch 是解析后的 AdminSettings
+type adminPatch struct {
+	level          *Level
+	groupLevels    map[string]*Level
+	caller         *bool
+	color          *bool
+	errTrackLevels []Level
+	setErrTrack    bool
+	trackBeautify  *bool
+}
+
+func parseAdminSettings(settings AdminSettings) (*adminPatch, error) {
+	patch := &adminPatch{
+		caller:        settings.Caller,
+		color:         settings.Color,
+		trackBeautify: settings.TrackBeautify,
+	}
+	if settings.Level != nil {
+		level, err := parseAdminLevel(*settings.Level)
+		if err != nil {
+			return nil, err
+		}
+		patch.level = &level
+	}
+	if len(settings.GroupLevels) > 0 {
+		patch.groupLevels = make(map[string]*Level, len(settings.GroupLevels))
+		for pattern, str := range settings.GroupLevels {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("group %q: invalid pattern", pattern)
+			}
+			if str == nil {
+				patch.groupLevels[pattern] = nil
+				continue
+			}
+			level, err := parseAdminLevel(*str)
+			if err != nil {
+				return nil, fmt.Errorf("group %q: %w", pattern, err)
+			}
+			patch.groupLevels[pattern] = &level
+		}
+	}
+	if settings.ErrTrackLevels != nil {
+		patch.setErrTrack = true
+		for _, str := range *settings.ErrTrackLevels {
+			level, err := parseAdminLevel(str)
+			if err != nil {
+				return nil, err
+			}
+			patch.errTrackLevels = append(patch.errTrackLevels, level)
+		}
+	}
+	return patch, nil
+}
+
+func parseAdminLevel(str string) (Level, error) {
+	var level Level
+	if err := level.UnmarshalText([]byte(str)); err != nil {
+		return 0, fmt.Errorf("invalid level %q", str)
+	}
+	return level, nil
+}
+
+func (p *adminPatch) apply(config LoggerConfiguration) {
+	if p.level != nil {
+		config.WithLeveler(*p.level)
+	}
+	for pattern, level := range p.groupLevels {
+		if level == nil {
+			config.WithGroupLevel(pattern, nil)
+		} else {
+			config.WithGroupLevel(pattern, *level)
+		}
+	}
+	if p.caller != nil {
+		config.WithCaller(*p.caller)
+	}
+	if p.color != nil {
+		config.WithEnableColor(*p.color)
+	}
+	if p.setErrTrack {
+		config.WithoutErrTrackLevel(config.FetchErrTrackLevels()...)
+		config.WithErrTrackLevel(p.errTrackLevels...)
+	}
+	if p.trackBeautify != nil {
+		config.WithTrackBeautify(*p.trackBeautify)
+	}
+}
+
+// copyAdminSettings 将 src 中 AdminSettings 所包含的配置项复制到 dst
+func copyAdminSettings(dst LoggerConfiguration, src LoggerOptionsFetcher) {
+	dst.WithLeveler(src.FetchLeveler())
+	groupLevels := src.FetchGroupLevels()
+	for pattern := range dst.FetchGroupLevels() {
+		if _, exist := groupLevels[pattern]; !exist {
+			dst.WithGroupLevel(pattern, nil)
+		}
+	}
+	patterns := make([]string, 0, len(groupLevels))
+	for pattern := range groupLevels {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	for _, pattern := range patterns {
+		dst.WithGroupLevel(pattern, groupLevels[pattern])
+	}
+	dst.WithCaller(src.FetchCaller())
+	dst.WithEnableColor(src.FetchEnableColor())
+	errTrackLevels := src.FetchErrTrackLevels()
+	dst.WithoutErrTrackLevel(slices.DeleteFunc(dst.FetchErrTrackLevels(), func(level Level) bool {
+		return slices.Contains(errTrackLevels, level)
+	})...)
+	dst.WithErrTrackLevel(errTrackLevels...)
+	dst.WithTrackBeautify(src.FetchTrackBeautify())
+}
+
+// replaceAdminSettings 将 src 中 AdminSettings 所包含的配置项整体替换到 dst 中
+//   - 当 dst 及 src 均由 GetConfigBuilder 创建时，所有配置项将在同一次加锁中被替换，否则将逐项复制
+func replaceAdminSettings(dst, src LoggerConfiguration) {
+	target, ok := dst.(*loggerConfiguration)
+	source, sok := src.(*loggerConfiguration)
+	if !ok || !sok {
+		copyAdminSettings(dst, src)
+		return
+	}
+
+	var staged loggerConfiguration
+	source.fetch(func(config *loggerConfiguration) {
+		staged.leveler = config.leveler
+		staged.groupLevels = config.groupLevels
+		staged.caller = config.caller
+		staged.enableColor = config.enableColor
+		staged.errTrackLevel = cloneMap(config.errTrackLevel)
+		staged.trackBeautify = config.trackBeautify
+	})
+	target.update(func(config *loggerConfiguration) {
+		config.leveler = staged.leveler
+		config.groupLevels = staged.groupLevels // 分组的日志级别在变更时整体替换，因此可以共享
+		config.groupLevelCache = new(groupLevelCache)
+		config.caller = staged.caller
+		config.enableColor = staged.enableColor
+		config.errTrackLevel = staged.errTrackLevel
+		config.trackBeautify = staged.trackBeautify
+	})
+}
+
+func writeAdminJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeAdminError(w http.ResponseWriter, status int, err error) {
+	writeAdminJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,97 @@
+package log
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestAdminHTTPHandler tests reading and changing a registered configuration, that temporary overrides revert
+// and that a partially invalid request leaves the configuration unchanged.
+func TestAdminHTTPHandler(t *testing.T) {
+	config := GetConfigBuilder().Production()
+	admin := NewAdminHTTPHandler(NewAdminHTTPOptions().WithAuthorizer(func(*http.Request) bool { return true })).Register("app", config)
+	defer admin.Close()
+	clock := time.Now()
+	admin.(*adminHTTPHandler).now = func() time.Time {
+		return clock
+	}
+	server := httptest.NewServer(admin)
+	defer server.Close()
+
+	do := func(method, path, body string) (int, AdminSettings) {
+		req, _ := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s error = %v", method, path, err)
+		}
+		defer resp.Body.Close()
+		var settings AdminSettings
+		_ = json.NewDecoder(resp.Body).Decode(&settings)
+		return resp.StatusCode, settings
+	}
+
+	status, settings := do(http.MethodGet, "/app", "")
+	if status != http.StatusOK || *settings.Level != "INFO" {
+		t.Fatalf("GET /app = %d, %+v", status, settings)
+	}
+
+	status, _ = do(http.MethodPut, "/app", `{"level":"warn","group_levels":{"database.*":"DEBUG"},"err_track_levels":["ERROR"]}`)
+	if status != http.StatusOK || config.FetchLeveler().Level() != LevelWarn || !config.FetchErrTrackLevel(LevelError) {
+		t.Fatalf("PUT /app = %d, level = %s", status, config.FetchLeveler().Level())
+	}
+	if leveler := config.FetchGroupLevel("database.orders"); leveler == nil || leveler.Level() != LevelDebug {
+		t.Fatalf("group level is not applied: %v", leveler)
+	}
+
+	status, settings = do(http.MethodPut, "/app", `{"level":"DEBUG","caller":false,"ttl":"10m"}`)
+	if status != http.StatusOK || settings.ExpiresAt == nil || config.FetchLeveler().Level() != LevelDebug || config.FetchCaller() {
+		t.Fatalf("temporary override is not applied: %d, %+v", status, settings)
+	}
+	clock = clock.Add(5 * time.Minute)
+	if status, settings = do(http.MethodGet, "/app", ""); settings.ExpiresAt == nil || config.FetchLeveler().Level() != LevelDebug {
+		t.Fatalf("temporary override is reverted before the ttl: %d, %+v", status, settings)
+	}
+	clock = clock.Add(5 * time.Minute)
+	if status, settings = do(http.MethodGet, "/app", ""); settings.ExpiresAt != nil || config.FetchLeveler().Level() != LevelWarn || !config.FetchCaller() {
+		t.Fatalf("temporary override is not reverted: %d, level = %s", status, config.FetchLeveler().Level())
+	}
+
+	if status, _ = do(http.MethodPut, "/app", `{"level":"LOUD"}`); status != http.StatusBadRequest {
+		t.Fatalf("invalid level status = %d, want 400", status)
+	}
+	if status, _ = do(http.MethodPut, "/app", `{"level":"ERROR","caller":false,"group_levels":{"[":"DEBUG"}}`); status != http.StatusBadRequest {
+		t.Fatalf("invalid pattern status = %d, want 400", status)
+	}
+	if config.FetchLeveler().Level() != LevelWarn || !config.FetchCaller() || len(config.FetchGroupLevels()) != 1 {
+		t.Fatalf("partially invalid request changed the configuration: level = %s", config.FetchLeveler().Level())
+	}
+	if status, _ = do(http.MethodGet, "/missing", ""); status != http.StatusNotFound {
+		t.Fatalf("missing configuration status = %d, want 404", status)
+	}
+}
+
+// TestAdminHTTPHandlerAuthorization tests that requests are rejected without an authorizer or when it denies them.
+func TestAdminHTTPHandlerAuthorization(t *testing.T) {
+	for _, c := range []struct {
+		name    string
+		options *AdminHTTPOptions
+		want    int
+	}{
+		{name: "default", options: nil, want: http.StatusForbidden},
+		{name: "denied", options: NewAdminHTTPOptions().WithAuthorizer(func(*http.Request) bool { return false }), want: http.StatusForbidden},
+		{name: "allowed", options: NewAdminHTTPOptions().WithAuthorizer(func(*http.Request) bool { return true }), want: http.StatusOK},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			admin := NewAdminHTTPHandler(c.options).Register("app", GetConfigBuilder().Production())
+			recorder := httptest.NewRecorder()
+			admin.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/app", nil))
+			if recorder.Code != c.want {
+				t.Fatalf("GET /app = %d, want %d", recorder.Code, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,268 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	_ AsyncHandler = (*asyncHandler)(nil)
+
+	// ErrAsyncClosed 是 AsyncHandler 关闭后继续处理日志记录时返回的错误
+	ErrAsyncClosed = errors.New("log async: handler closed")
+)
+
+// AsyncOptions 是 AsyncHandler 的选项
+type AsyncOptions struct {
+	workers      int             // 后台协程数量
+	queueSize    int             // 每个后台协程的队列长度
+	dropOnFull   bool            // 队列已满时是否丢弃日志记录
+	errorHandler func(err error) // 后台处理失败时的错误处理函数
+}
+
+// NewAsyncOptions 创建一个默认的 AsyncOptions
+//   - 默认使用 4 个后台协程，每个后台协程的队列长度为 1024，队列已满时 Handle 将阻塞
+func NewAsyncOptions() *AsyncOptions {
+	return &AsyncOptions{
+		workers:   4,
+		queueSize: 1024,
+	}
+}
+
+// WithWorkers 设置后台协程数量
+func (o *AsyncOptions) WithWorkers(workers int) *AsyncOptions {
+	o.workers = workers
+	return o
+}
+
+// WithQueueSize 设置每个后台协程的队列长度
+func (o *AsyncOptions) WithQueueSize(size int) *AsyncOptions {
+	o.queueSize = size
+	return o
+}
+
+// WithDropOnFull 设置队列已满时是否丢弃日志记录，否则 Handle 将阻塞直到队列存在空位
+func (o *AsyncOptions) WithDropOnFull(drop bool) *AsyncOptions {
+	o.dropOnFull = drop
+	return o
+}
+
+// WithErrorHandler 设置被包装的处理器在后台处理失败时的错误处理函数，处理器发生的 panic 将被转换为错误后传递给该函数
+func (o *AsyncOptions) WithErrorHandler(handler func(err error)) *AsyncOptions {
+	o.errorHandler = handler
+	return o
+}
+
+// AsyncHandler 是一个在后台协程中格式化及写入日志记录的日志处理器
+//   - 调用栈、属性中的 slog.LogValuer 以及 WithAttrs 中的属性将在调用者的协程中被捕获及解析，以保证输出与同步处理一致
+//   - 同一个 Logger 的日志记录总是由同一个后台协程按顺序处理，通过 With 或 WithGroup 派生的 Logger 可能由其他后台协程处理
+type AsyncHandler interface {
+	Handler
+
+	// Flush 等待所有已提交的日志记录处理完成
+	Flush() error
+
+	// Close 处理所有已提交的日志记录并停止后台协程，关闭后的日志记录将被拒绝
+	Close() error
+
+	// Dropped 获取因队列已满而被丢弃的日志记录数量
+	Dropped() uint64
+}
+
+// NewAsyncHandler 创建一个包装 handler 的异步日志处理器，当 options 为 nil 时将使用默认选项
+func NewAsyncHandler(handler Handler, options *AsyncOptions) AsyncHandler {
+	if options == nil {
+		options = NewAsyncOptions()
+	}
+	p := &asyncPool{
+		queues:       make([]chan asyncJob, max(options.workers, 1)),
+		dropOnFull:   options.dropOnFull,
+		errorHandler: options.errorHandler,
+	}
+	for i := range p.queues {
+		p.queues[i] = make(chan asyncJob, max(options.queueSize, 0))
+		p.wg.Add(1)
+		go p.run(p.queues[i])
+	}
+	return p.view(handler)
+}
+
+type asyncHandler struct {
+	pool    *asyncPool   // 后台协程池，它在所有派生的处理器间共享
+	handler slog.Handler // 被包装的处理器
+	queue   int          // 处理该处理器日志记录的后台协程
+}
+
+func (h *asyncHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *asyncHandler) Handle(ctx context.Context, record slog.Record) error {
+	ctx = withCallerFrames(ctx)
+	ctx, record = resolveContextAttrs(ctx, record)
+	return h.pool.submit(h.queue, asyncJob{
+		ctx:     context.WithoutCancel(ctx),
+		handler: h.handler,
+		record:  resolveRecord(record),
+	})
+}
+
+func (h *asyncHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	resolved := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		resolved[i] = resolveAttr(attr)
+	}
+	return h.pool.view(h.handler.WithAttrs(resolved))
+}
+
+func (h *asyncHandler) WithGroup(name string) slog.Handler {
+	return h.pool.view(h.handler.WithGroup(name))
+}
+
+func (h *asyncHandler) Flush() error {
+	return h.pool.flush()
+}
+
+func (h *asyncHandler) Close() error {
+	return h.pool.close()
+}
+
+func (h *asyncHandler) Dropped() uint64 {
+	return h.pool.dropped.Load()
+}
+
+// resolveRecord 创建一个属性均已被解析的日志记录副本
+func resolveRecord(record slog.Record) slog.Record {
+	resolved := slog.NewRecord(record.Time, record.Level, record.Message, record.PC)
+	record.Attrs(func(attr slog.Attr) bool {
+		resolved.AddAttrs(resolveAttr(attr))
+		return true
+	})
+	return resolved
+}
+
+// resolveAttr 递归地解析属性中的 slog.LogValuer
+func resolveAttr(attr slog.Attr) slog.Attr {
+	attr.Value = attr.Value.Resolve()
+	if attr.Value.Kind() == slog.KindGroup {
+		group := attr.Value.Group()
+		resolved := make([]slog.Attr, len(group))
+		for i, a := range group {
+			resolved[i] = resolveAttr(a)
+		}
+		attr.Value = slog.GroupValue(resolved...)
+	}
+	return attr
+}
+
+type asyncJob struct {
+	ctx     context.Context
+	handler slog.Handler
+	record  slog.Record
+	flushed chan struct{} // 当不为 nil 时表示这是一个 Flush 标记
+}
+
+type asyncPool struct {
+	rw           sync.RWMutex
+	queues       []chan asyncJob
+	next         atomic.Uint32 // 下一个派生的处理器所使用的后台协程
+	dropOnFull   bool
+	errorHandler func(err error)
+	dropped      atomic.Uint64
+	closed       bool
+	wg           sync.WaitGroup
+}
+
+// view 创建一个绑定到某个后台协程的处理器
+func (p *asyncPool) view(handler slog.Handler) *asyncHandler {
+	return &asyncHandler{
+		pool:    p,
+		handler: handler,
+		queue:   int((p.next.Add(1) - 1) % uint32(len(p.queues))),
+	}
+}
+
+func (p *asyncPool) submit(queue int, job asyncJob) error {
+	p.rw.RLock()
+	defer p.rw.RUnlock()
+	if p.closed {
+		return ErrAsyncClosed
+	}
+	if !p.dropOnFull {
+		p.queues[queue] <- job
+		return nil
+	}
+	select {
+	case p.queues[queue] <- job:
+	default:
+		p.dropped.Add(1)
+	}
+	return nil
+}
+
+func (p *asyncPool) flush() error {
+	p.rw.RLock()
+	if p.closed {
+		p.rw.RUnlock()
+		return ErrAsyncClosed
+	}
+	markers := make([]chan struct{}, len(p.queues))
+	for i, queue := range p.queues {
+		markers[i] = make(chan struct{})
+		queue <- asyncJob{flushed: markers[i]}
+	}
+	p.rw.RUnlock()
+
+	for _, marker := range markers {
+		<-marker
+	}
+	return nil
+}
+
+func (p *asyncPool) close() error {
+	p.rw.Lock()
+	if p.closed {
+		p.rw.Unlock()
+		return ErrAsyncClosed
+	}
+	p.closed = true
+	for _, queue := range p.queues {
+		close(queue)
+	}
+	p.rw.Unlock()
+
+	p.wg.Wait()
+	return nil
+}
+
+func (p *asyncPool) run(queue chan asyncJob) {
+	defer p.wg.Done()
+	for job := range queue {
+		if job.flushed != nil {
+			close(job.flushed)
+			continue
+		}
+		if err := p.handle(job); err != nil && p.errorHandler != nil {
+			p.errorHandler(err)
+		}
+	}
+}
+
+// handle 使用被包装的处理器处理日志记录，并将 panic 转换为错误，避免工作协程退出
+func (p *asyncPool) handle(job asyncJob) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			switch v := v.(type) {
+			case error:
+				err = fmt.Errorf("recover from panic: %w", v)
+			default:
+				err = fmt.Errorf("recover from panic: %v", v)
+			}
+		}
+	}()
+	return job.handler.Handle(job.ctx, job.record)
+}
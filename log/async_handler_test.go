@@ -0,0 +1,98 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type asyncTestValuer struct {
+	value *string
+}
+
+func (v asyncTestValuer) LogValue() slog.Value {
+	return slog.StringValue(*v.value)
+}
+
+// TestAsyncHandler tests that records keep their order per logger and LogValuer values are resolved before the hand-off.
+func TestAsyncHandler(t *testing.T) {
+	var buf bytes.Buffer
+	async := NewAsyncHandler(newHandler(GetConfigBuilder().Test().WithWriter(&buf)), NewAsyncOptions().WithWorkers(2))
+	logger := GetBuilder().FromHandler(async)
+
+	value := "before"
+	logger.Info("resolved", "valuer", asyncTestValuer{value: &value})
+	value = "after"
+	for i := 0; i < 100; i++ {
+		logger.Info("ordered", "seq", i)
+	}
+	if err := async.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "before") || strings.Contains(out, "after") {
+		t.Fatalf("LogValuer is not resolved before the hand-off: %s", out)
+	}
+	seq := 0
+	for _, line := range strings.Split(out, "\n") {
+		if !strings.Contains(line, "Ordered") {
+			continue
+		}
+		if !strings.Contains(line, "async_handler_test.go") || !strings.HasSuffix(line, fmt.Sprint(seq)) {
+			t.Fatalf("record %d is out of order or has a wrong caller: %s", seq, line)
+		}
+		seq++
+	}
+	if seq != 100 {
+		t.Fatalf("got %d ordered records, want 100", seq)
+	}
+
+	if err := async.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if err := async.Handle(context.Background(), slog.Record{}); !errors.Is(err, ErrAsyncClosed) {
+		t.Fatalf("Handle() after Close() error = %v, want ErrAsyncClosed", err)
+	}
+}
+
+// TestAsyncHandlerPanic tests that a panicking handler is reported to the error handler without stopping the worker.
+func TestAsyncHandlerPanic(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		errs    []error
+		handled atomic.Int32
+	)
+	inner := &multiTestHandler{Handler: newSilentHandler(), handle: func(context.Context) error {
+		if handled.Add(1) == 1 {
+			panic("boom")
+		}
+		return nil
+	}}
+	async := NewAsyncHandler(inner, NewAsyncOptions().WithWorkers(1).WithErrorHandler(func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		errs = append(errs, err)
+	}))
+	defer async.Close()
+
+	for i := 0; i < 3; i++ {
+		_ = async.Handle(context.Background(), slog.NewRecord(time.Now(), LevelInfo, "record", 0))
+	}
+	if err := async.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if handled.Load() != 3 || len(errs) != 1 || errs[0].Error() != "recover from panic: boom" {
+		t.Fatalf("handled = %d, errors = %v, want 3 records and the recovered panic", handled.Load(), errs)
+	}
+}
@@ -0,0 +1,56 @@
+package log
+
+import (
+	"context"
+	"runtime"
+)
+
+const callerFramesDepth = 32 // 捕获调用栈的最大深度
+
+type callerFramesKey struct{}
+
+// withCallerFrames 是包内处理器所使用的 WithCallerFrames，二者为同一个函数，因此调用栈层数保持一致
+var withCallerFrames = WithCallerFrames
+
+// WithCallerFrames 在上下文中记录调用 Handle 时的调用栈，如果上下文中已经存在调用栈，那么将保持不变
+//   - 它被用于在其他包中实现包装 Handler 的处理器，此类处理器应在 Handle 的第一时间调用该函数，以保证被包装的处理器能够获取正确的调用者信息
+//   - 该函数必须直接在 Handle 函数中调用，以保证调用栈层数与 CallerSkip 保持一致
+func WithCallerFrames(ctx context.Context) context.Context {
+	// 0: runtime.Callers, 1: captureCallerFrames, 2: WithCallerFrames, 3: Handle, 4: Handle 的调用者
+	return captureCallerFrames(ctx, 4)
+}
+
+func captureCallerFrames(ctx context.Context, skip int) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if _, exist := ctx.Value(callerFramesKey{}).([]uintptr); exist {
+		return ctx
+	}
+
+	pcs := make([]uintptr, callerFramesDepth)
+	n := runtime.Callers(skip, pcs)
+	return context.WithValue(ctx, callerFramesKey{}, pcs[:n])
+}
+
+// callerFrames 获取上下文中记录的调用栈，skip 与 CallerSkip 语义一致
+func callerFrames(ctx context.Context, skip int) []uintptr {
+	if ctx == nil {
+		return nil
+	}
+	pcs, _ := ctx.Value(callerFramesKey{}).([]uintptr)
+	// CallerSkip 以 runtime.Callers 在格式化函数中的调用为基准，其中前 3 层分别为 runtime.Callers、格式化函数及 Handle
+	skip -= 3
+	if skip < 0 || skip >= len(pcs) {
+		return nil
+	}
+	return pcs[skip:]
+}
+
+// withoutCallerFrames 在上下文中记录一个空的调用栈，它被用于处理器自身产生的日志记录，例如摘要或事件，使其不包含调用者信息
+func withoutCallerFrames(ctx context.Context) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, callerFramesKey{}, []uintptr{})
+}
@@ -0,0 +1,332 @@
+package log
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var _ CircuitBreakerWriter = (*circuitBreakerWriter)(nil)
+
+var (
+	// ErrCircuitTimeout 是日志写入器写入超时时的错误，它仅出现在 CircuitEvent 中
+	ErrCircuitTimeout = errors.New("log circuit breaker: write timeout")
+
+	// ErrCircuitSlowWrite 是日志写入器写入完成但耗时超过阈值时的错误，它仅出现在 CircuitEvent 中
+	ErrCircuitSlowWrite = errors.New("log circuit breaker: slow write")
+
+	// errCircuitBusy 是由于其他写入尚未完成而等待写入许可超时时的错误，它不会被计为一次失败
+	errCircuitBusy = errors.New("log circuit breaker: previous write still in progress")
+)
+
+// CircuitState 是熔断器的状态
+type CircuitState int
+
+const (
+	CircuitClosed   CircuitState = iota // 关闭，日志写入到主写入器
+	CircuitOpen                         // 打开，日志写入到后备写入器
+	CircuitHalfOpen                     // 半开，正在通过一次写入探测主写入器是否恢复
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// CircuitEvent 是熔断器的状态变更事件
+type CircuitEvent struct {
+	From  CircuitState // 变更前的状态
+	To    CircuitState // 变更后的状态
+	Cause error        // 导致熔断器打开的最后一次写入错误，其他变更时为 nil
+	Time  time.Time    // 变更的时间
+}
+
+// CircuitBreakerOptions 是 CircuitBreakerWriter 的选项
+type CircuitBreakerOptions struct {
+	slowThreshold    time.Duration            // 慢写入的耗时阈值
+	timeout          time.Duration            // 等待主写入器写入完成的最长时间
+	failureThreshold int                      // 打开熔断器所需的连续慢写入或失败次数
+	openDuration     time.Duration            // 熔断器打开后到进行半开探测的时间
+	fallback         io.Writer                // 后备写入器
+	onStateChange    func(event CircuitEvent) // 状态变更事件的处理函数
+}
+
+// NewCircuitBreakerOptions 创建一个默认的 CircuitBreakerOptions
+//   - 默认耗时超过 500 毫秒的写入视为慢写入，最多等待 2 秒，连续 3 次慢写入或失败后打开熔断器，10 秒后进行半开探测
+//   - 默认不设置后备写入器，熔断器打开期间的日志将被丢弃并计数
+func NewCircuitBreakerOptions() *CircuitBreakerOptions {
+	return &CircuitBreakerOptions{
+		slowThreshold:    500 * time.Millisecond,
+		timeout:          2 * time.Second,
+		failureThreshold: 3,
+		openDuration:     10 * time.Second,
+	}
+}
+
+// WithSlowThreshold 设置慢写入的耗时阈值，当 threshold <= 0 时表示不检测慢写入
+func (o *CircuitBreakerOptions) WithSlowThreshold(threshold time.Duration) *CircuitBreakerOptions {
+	o.slowThreshold = threshold
+	return o
+}
+
+// WithTimeout 设置等待主写入器写入完成的最长时间，当 timeout <= 0 时表示一直等待
+//   - 超时的写入不会被中断，它将在后台继续进行，在其完成前后续的写入均将等待，因此日志的顺序不会被打乱
+//   - 写入的耗时从获得写入许可时开始计算，等待其他写入完成的时间使用单独的等待时间限制，等待超时的日志将被写入到后备写入器中
+//   - 仅当等待的是一次已超时的写入时，等待超时才会被计为一次失败，并发写入之间的排队不会被计为失败
+//   - 超时的日志将同时被写入到后备写入器中，当主写入器最终完成写入时，它可能会出现在两个写入器中
+func (o *CircuitBreakerOptions) WithTimeout(timeout time.Duration) *CircuitBreakerOptions {
+	o.timeout = timeout
+	return o
+}
+
+// WithFailureThreshold 设置打开熔断器所需的连续慢写入或失败次数
+func (o *CircuitBreakerOptions) WithFailureThreshold(threshold int) *CircuitBreakerOptions {
+	o.failureThreshold = threshold
+	return o
+}
+
+// WithOpenDuration 设置熔断器打开后到进行半开探测的时间
+func (o *CircuitBreakerOptions) WithOpenDuration(duration time.Duration) *CircuitBreakerOptions {
+	o.openDuration = duration
+	return o
+}
+
+// WithFallback 设置熔断器打开或写入失败时所使用的后备写入器，例如 os.Stderr，当 fallback 为 nil 时日志将被丢弃并计数
+func (o *CircuitBreakerOptions) WithFallback(fallback io.Writer) *CircuitBreakerOptions {
+	o.fallback = fallback
+	return o
+}
+
+// WithOnStateChange 设置状态变更事件的处理函数，它在写入日志的协程中被同步调用
+//   - 处理函数不应当使用该写入器记录日志，否则可能导致递归
+func (o *CircuitBreakerOptions) WithOnStateChange(handler func(event CircuitEvent)) *CircuitBreakerOptions {
+	o.onStateChange = handler
+	return o
+}
+
+// CircuitBreakerWriter 是一个保护性的日志写入器，它测量主写入器的写入耗时，并在连续的慢写入或失败后打开熔断器
+//   - 熔断器打开期间，日志将被写入到后备写入器中，调用者不会被阻塞
+//   - 经过一段时间后，熔断器将进入半开状态并使用一次写入探测主写入器，成功时关闭熔断器，否则重新打开
+type CircuitBreakerWriter interface {
+	io.Writer
+
+	// State 获取熔断器的当前状态
+	State() CircuitState
+
+	// Diverted 获取被写入到后备写入器或被丢弃的日志数量
+	Diverted() uint64
+
+	// Dropped 获取未设置后备写入器时被丢弃的日志数量
+	Dropped() uint64
+}
+
+// NewCircuitBreakerWriter 创建一个包装 writer 的熔断日志写入器，当 options 为 nil 时将使用默认选项
+func NewCircuitBreakerWriter(writer io.Writer, options *CircuitBreakerOptions) CircuitBreakerWriter {
+	if options == nil {
+		options = NewCircuitBreakerOptions()
+	}
+	return &circuitBreakerWriter{
+		writer:           writer,
+		slowThreshold:    options.slowThreshold,
+		timeout:          options.timeout,
+		failureThreshold: max(options.failureThreshold, 1),
+		openDuration:     options.openDuration,
+		fallback:         options.fallback,
+		onStateChange:    options.onStateChange,
+		sem:              make(chan struct{}, 1),
+		now:              time.Now,
+	}
+}
+
+type circuitBreakerWriter struct {
+	writer           io.Writer
+	slowThreshold    time.Duration
+	timeout          time.Duration
+	failureThreshold int
+	openDuration     time.Duration
+	fallback         io.Writer
+	onStateChange    func(event CircuitEvent)
+	sem              chan struct{}    // 主写入器的写入许可，用于保证写入的顺序
+	now              func() time.Time // 状态变更所使用的时钟
+	stuck            atomic.Int32     // 已超时但仍未完成的写入数量
+
+	rw       sync.Mutex
+	state    CircuitState
+	failures int       // 连续慢写入或失败次数
+	openedAt time.Time // 熔断器打开的时间
+	diverted atomic.Uint64
+	dropped  atomic.Uint64
+}
+
+func (w *circuitBreakerWriter) Write(p []byte) (n int, err error) {
+	probe, allowed, event := w.acquire()
+	w.emit(event)
+	if !allowed {
+		return w.divert(p)
+	}
+
+	err = w.writePrimary(p)
+	w.emit(w.release(probe, err))
+	if err != nil && !errors.Is(err, ErrCircuitSlowWrite) {
+		return w.divert(p)
+	}
+	return len(p), nil
+}
+
+func (w *circuitBreakerWriter) State() CircuitState {
+	w.rw.Lock()
+	defer w.rw.Unlock()
+	return w.state
+}
+
+func (w *circuitBreakerWriter) Diverted() uint64 {
+	return w.diverted.Load()
+}
+
+func (w *circuitBreakerWriter) Dropped() uint64 {
+	return w.dropped.Load()
+}
+
+// acquire 检查本次写入是否可以使用主写入器，以及本次写入是否为半开探测
+func (w *circuitBreakerWriter) acquire() (probe, allowed bool, event *CircuitEvent) {
+	w.rw.Lock()
+	defer w.rw.Unlock()
+	switch w.state {
+	case CircuitClosed:
+		return false, true, nil
+	case CircuitOpen:
+		if w.now().Sub(w.openedAt) >= w.openDuration {
+			return true, true, w.transition(CircuitHalfOpen, nil)
+		}
+	}
+	// 半开状态下仅允许一次探测
+	return false, false, nil
+}
+
+// release 记录本次写入的结果，并在需要时变更熔断器的状态
+func (w *circuitBreakerWriter) release(probe bool, err error) *CircuitEvent {
+	w.rw.Lock()
+	defer w.rw.Unlock()
+	if errors.Is(err, errCircuitBusy) {
+		// 半开探测无法进行时，主写入器仍被其他写入占用，因此重新打开熔断器
+		if probe {
+			w.openedAt = w.now()
+			return w.transition(CircuitOpen, ErrCircuitTimeout)
+		}
+		return nil
+	}
+	if err == nil {
+		w.failures = 0
+		if probe {
+			return w.transition(CircuitClosed, nil)
+		}
+		return nil
+	}
+
+	w.failures++
+	switch {
+	case probe:
+		w.openedAt = w.now()
+		return w.transition(CircuitOpen, err)
+	case w.state == CircuitClosed && w.failures >= w.failureThreshold:
+		w.openedAt = w.now()
+		return w.transition(CircuitOpen, err)
+	}
+	return nil
+}
+
+func (w *circuitBreakerWriter) transition(to CircuitState, cause error) *CircuitEvent {
+	event := &CircuitEvent{From: w.state, To: to, Cause: cause, Time: w.now()}
+	w.state = to
+	return event
+}
+
+func (w *circuitBreakerWriter) emit(event *CircuitEvent) {
+	if event != nil && w.onStateChange != nil {
+		w.onStateChange(*event)
+	}
+}
+
+// writePrimary 在超时限制下使用主写入器写入，慢写入将返回 ErrCircuitSlowWrite
+//   - 写入的耗时及超时均从获得写入许可时开始计算，等待写入许可超时时，若存在已超时的写入则返回 ErrCircuitTimeout，否则返回 errCircuitBusy
+func (w *circuitBreakerWriter) writePrimary(p []byte) error {
+	var start time.Time
+	var err error
+	if w.timeout <= 0 {
+		w.sem <- struct{}{}
+		start = time.Now()
+		err = circuitWrite(w.writer, p)
+		<-w.sem
+	} else {
+		wait := time.NewTimer(w.timeout)
+		select {
+		case w.sem <- struct{}{}:
+			wait.Stop()
+		case <-wait.C:
+			if w.stuck.Load() > 0 {
+				// 上一次超时的写入仍未完成
+				return ErrCircuitTimeout
+			}
+			return errCircuitBusy
+		}
+
+		start = time.Now()
+		timer := time.NewTimer(w.timeout)
+		defer timer.Stop()
+
+		// 超时后写入仍将继续，因此需要复制 p
+		data := append([]byte(nil), p...)
+		var abandoned atomic.Bool
+		done := make(chan error, 1)
+		go func() {
+			err := circuitWrite(w.writer, data)
+			if !abandoned.CompareAndSwap(false, true) {
+				w.stuck.Add(-1)
+			}
+			<-w.sem
+			done <- err
+		}()
+		select {
+		case err = <-done:
+		case <-timer.C:
+			if abandoned.CompareAndSwap(false, true) {
+				w.stuck.Add(1)
+				return ErrCircuitTimeout
+			}
+			// 写入恰好在超时时完成
+			err = <-done
+		}
+	}
+
+	if err == nil && w.slowThreshold > 0 && time.Since(start) >= w.slowThreshold {
+		return ErrCircuitSlowWrite
+	}
+	return err
+}
+
+func circuitWrite(writer io.Writer, p []byte) error {
+	n, err := writer.Write(p)
+	if err == nil && n < len(p) {
+		err = io.ErrShortWrite
+	}
+	return err
+}
+
+// divert 将日志写入到后备写入器，未设置后备写入器时丢弃日志
+func (w *circuitBreakerWriter) divert(p []byte) (int, error) {
+	w.diverted.Add(1)
+	if w.fallback == nil {
+		w.dropped.Add(1)
+		return len(p), nil
+	}
+	return w.fallback.Write(p)
+}
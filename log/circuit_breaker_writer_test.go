@@ -0,0 +1,192 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+type blockingWriter struct {
+	release chan struct{}
+	mu      sync.Mutex
+	buf     bytes.Buffer
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	<-w.release
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *blockingWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+type failingWriter struct {
+	err error
+}
+
+func (w *failingWriter) Write([]byte) (int, error) {
+	return 0, w.err
+}
+
+// newCircuitBreakerTestWriter creates a writer driven by a manual clock, the returned function advances the clock.
+func newCircuitBreakerTestWriter(primary io.Writer, options *CircuitBreakerOptions) (CircuitBreakerWriter, func(d time.Duration)) {
+	writer := NewCircuitBreakerWriter(primary, options).(*circuitBreakerWriter)
+	clock := time.Now()
+	writer.now = func() time.Time {
+		return clock
+	}
+	return writer, func(d time.Duration) {
+		clock = clock.Add(d)
+	}
+}
+
+// TestCircuitBreakerWriter tests that a hung writer opens the circuit, records are diverted to the fallback,
+// and a half-open probe closes the circuit once the writer recovers.
+func TestCircuitBreakerWriter(t *testing.T) {
+	const openDuration = time.Minute
+	primary := &blockingWriter{release: make(chan struct{})}
+	var fallback bytes.Buffer
+	var events []CircuitEvent
+	writer, advance := newCircuitBreakerTestWriter(primary, NewCircuitBreakerOptions().
+		WithTimeout(100*time.Millisecond).
+		WithSlowThreshold(0).
+		WithFailureThreshold(2).
+		WithOpenDuration(openDuration).
+		WithFallback(&fallback).
+		WithOnStateChange(func(event CircuitEvent) {
+			events = append(events, event)
+		}),
+	)
+
+	for _, line := range []string{"a\n", "b\n", "c\n"} {
+		if _, err := writer.Write([]byte(line)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	if writer.State() != CircuitOpen || fallback.String() != "a\nb\nc\n" || writer.Diverted() != 3 {
+		t.Fatalf("state = %v, fallback = %q, diverted = %d", writer.State(), fallback.String(), writer.Diverted())
+	}
+	if len(events) != 1 || events[0].To != CircuitOpen || !errors.Is(events[0].Cause, ErrCircuitTimeout) {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+
+	// the circuit stays open until the open duration has passed
+	advance(openDuration / 2)
+	_, _ = writer.Write([]byte("skipped\n"))
+	if writer.State() != CircuitOpen || len(events) != 1 {
+		t.Fatalf("circuit is probed before the open duration: state = %v, events = %+v", writer.State(), events)
+	}
+
+	// wait for the timed out write to complete so that the probe does not queue behind it
+	close(primary.release)
+	for deadline := time.Now().Add(5 * time.Second); primary.String() != "a\n"; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out write did not complete, primary = %q", primary.String())
+		}
+	}
+
+	advance(openDuration / 2)
+	if _, err := writer.Write([]byte("d\n")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if writer.State() != CircuitClosed || len(events) != 3 || events[1].To != CircuitHalfOpen || events[2].To != CircuitClosed {
+		t.Fatalf("state = %v, events = %+v", writer.State(), events)
+	}
+	if primary.String() != "a\nd\n" {
+		t.Fatalf("primary = %q, want the timed out write followed by the probe", primary.String())
+	}
+}
+
+// TestCircuitBreakerWriterError tests that failed writes are diverted and open the circuit with the write error as cause.
+func TestCircuitBreakerWriterError(t *testing.T) {
+	broken := errors.New("broken")
+	var fallback bytes.Buffer
+	var events []CircuitEvent
+	writer, advance := newCircuitBreakerTestWriter(&failingWriter{err: broken}, NewCircuitBreakerOptions().
+		WithFailureThreshold(2).
+		WithFallback(&fallback).
+		WithOnStateChange(func(event CircuitEvent) {
+			events = append(events, event)
+		}),
+	)
+
+	_, _ = writer.Write([]byte("a\n"))
+	if writer.State() != CircuitClosed {
+		t.Fatalf("circuit opens before the failure threshold: %v", writer.State())
+	}
+	_, _ = writer.Write([]byte("b\n"))
+	if writer.State() != CircuitOpen || fallback.String() != "a\nb\n" || writer.Diverted() != 2 {
+		t.Fatalf("state = %v, fallback = %q, diverted = %d", writer.State(), fallback.String(), writer.Diverted())
+	}
+	if len(events) != 1 || !errors.Is(events[0].Cause, broken) {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+
+	// a failed probe reopens the circuit
+	advance(time.Hour)
+	_, _ = writer.Write([]byte("c\n"))
+	if writer.State() != CircuitOpen || len(events) != 3 || events[1].To != CircuitHalfOpen || events[2].To != CircuitOpen {
+		t.Fatalf("state = %v, events = %+v", writer.State(), events)
+	}
+}
+
+// TestCircuitBreakerWriterDropped tests that records are dropped and counted when no fallback is set.
+func TestCircuitBreakerWriterDropped(t *testing.T) {
+	writer, _ := newCircuitBreakerTestWriter(&failingWriter{err: errors.New("broken")}, NewCircuitBreakerOptions().WithFailureThreshold(1))
+
+	for i := 0; i < 3; i++ {
+		if n, err := writer.Write([]byte("a\n")); n != 2 || err != nil {
+			t.Fatalf("Write() = %d, %v, want the record to be dropped silently", n, err)
+		}
+	}
+	if writer.State() != CircuitOpen || writer.Dropped() != 3 || writer.Diverted() != 3 {
+		t.Fatalf("state = %v, dropped = %d, diverted = %d", writer.State(), writer.Dropped(), writer.Diverted())
+	}
+}
+
+type sleepWriter struct {
+	delay time.Duration
+	mu    sync.Mutex
+	lines int
+}
+
+func (w *sleepWriter) Write(p []byte) (int, error) {
+	time.Sleep(w.delay)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.lines++
+	return len(p), nil
+}
+
+// TestCircuitBreakerWriterConcurrent tests that waiting behind concurrent writers is not counted as a slow write.
+func TestCircuitBreakerWriterConcurrent(t *testing.T) {
+	const writers = 50
+	primary := &sleepWriter{delay: 2 * time.Millisecond}
+	writer, _ := newCircuitBreakerTestWriter(primary, NewCircuitBreakerOptions().
+		WithTimeout(5*time.Second).
+		WithSlowThreshold(50*time.Millisecond).
+		WithFailureThreshold(3),
+	)
+
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = writer.Write([]byte("a\n"))
+		}()
+	}
+	wg.Wait()
+
+	if writer.State() != CircuitClosed || writer.Diverted() != 0 || primary.lines != writers {
+		t.Fatalf("state = %v, diverted = %d, primary lines = %d, want closed, 0 and %d", writer.State(), writer.Diverted(), primary.lines, writers)
+	}
+}
@@ -0,0 +1,56 @@
+// logchain 校验由 log.HashChainWriter 写入的防篡改日志文件，并报告第一个断裂的位置
+//
+// 用法：
+//
+//	logchain [-key hex] [-checkpoint-key hex] file...
+//
+// 多个文件将按顺序校验，后一个文件将延续前一个文件的链，密钥也可以通过环境变量 LOGCHAIN_KEY 及 LOGCHAIN_CHECKPOINT_KEY 提供
+package main
+
+import (
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"github.com/kercylan98/go-log/log"
+	"os"
+)
+
+func main() {
+	var key, checkpointKey string
+	flag.StringVar(&key, "key", os.Getenv("LOGCHAIN_KEY"), "hex encoded chain key")
+	flag.StringVar(&checkpointKey, "checkpoint-key", os.Getenv("LOGCHAIN_CHECKPOINT_KEY"), "hex encoded checkpoint key")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	options := log.NewHashChainOptions()
+	if key != "" {
+		options.WithKey(mustDecodeHex("key", key))
+	}
+	if checkpointKey != "" {
+		options.WithCheckpointKey(mustDecodeHex("checkpoint-key", checkpointKey))
+	}
+
+	report, err := log.VerifyHashChainFiles(options, flag.Args()...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if report.Broken != nil {
+		fmt.Println(report.Broken.Error())
+		os.Exit(1)
+	}
+	fmt.Printf("ok: %d records, %d checkpoints, head %d:%x\n", report.Records, report.Checkpoints, report.Seq, report.Hash)
+}
+
+func mustDecodeHex(name, value string) []byte {
+	b, err := hex.DecodeString(value)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid %s: %v\n", name, err)
+		os.Exit(2)
+	}
+	return b
+}
@@ -0,0 +1,87 @@
+// logdecrypt 解密由 log.NewEncryptWriter 写入的日志文件，并将明文输出到标准输出，以便通过管道交给其他工具处理
+//
+// 用法：
+//
+//	logdecrypt -key id=hex [-key id=hex ...] [file...]
+//
+// 未指定文件时将从标准输入读取，密钥也可以通过环境变量 LOGDECRYPT_KEYS 以 "id=hex,id=hex" 的形式提供
+// 当文件末尾存在不完整的块时，将输出所有完整块的明文并在标准错误中给出警告
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/kercylan98/go-log/log"
+	"io"
+	"os"
+	"strings"
+)
+
+type keyFlags []string
+
+func (k *keyFlags) String() string {
+	return strings.Join(*k, ",")
+}
+
+func (k *keyFlags) Set(value string) error {
+	*k = append(*k, value)
+	return nil
+}
+
+func main() {
+	var keys keyFlags
+	flag.Var(&keys, "key", "decryption key in the form id=hex, can be repeated")
+	flag.Parse()
+
+	if env := os.Getenv("LOGDECRYPT_KEYS"); env != "" {
+		keys = append(keys, strings.Split(env, ",")...)
+	}
+	if len(keys) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	options := log.NewEncryptionOptions()
+	for _, key := range keys {
+		id, value, ok := strings.Cut(key, "=")
+		if !ok {
+			fatalf("invalid key %q, want id=hex", key)
+		}
+		b, err := hex.DecodeString(value)
+		if err != nil {
+			fatalf("invalid key %q: %v", id, err)
+		}
+		options.WithDecryptKey(id, b)
+	}
+
+	if flag.NArg() == 0 {
+		decrypt("<stdin>", os.Stdin, options)
+		return
+	}
+	for _, path := range flag.Args() {
+		f, err := os.Open(path)
+		if err != nil {
+			fatalf("%v", err)
+		}
+		decrypt(path, f, options)
+		_ = f.Close()
+	}
+}
+
+func decrypt(name string, reader io.Reader, options *log.EncryptionOptions) {
+	_, err := io.Copy(os.Stdout, log.NewDecryptReader(reader, options))
+	switch {
+	case err == nil:
+	case errors.Is(err, log.ErrTruncatedChunk):
+		fmt.Fprintf(os.Stderr, "warning: %s: %v\n", name, err)
+	default:
+		fatalf("%s: %v", name, err)
+	}
+}
+
+func fatalf(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
@@ -0,0 +1,64 @@
+package log
+
+import (
+	"context"
+	"log/slog"
+)
+
+// ContextExtractor 是从上下文中提取属性的函数，例如从上下文中提取 request_id、user_id 或租户信息
+//   - 当上下文中不存在相关的值时，应当返回 nil
+type ContextExtractor func(ctx context.Context) []Attr
+
+type contextAttrsKey struct{}
+
+// ContextWithAttrs 返回一个包含 attrs 的上下文，通过该上下文记录的日志均将包含这些属性，它通常被用于中间件
+//   - 多次调用时属性将被追加，原有的上下文不受影响
+func ContextWithAttrs(ctx context.Context, attrs ...Attr) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if len(attrs) == 0 {
+		return ctx
+	}
+	existing := AttrsFromContext(ctx)
+	return context.WithValue(ctx, contextAttrsKey{}, append(existing[:len(existing):len(existing)], attrs...))
+}
+
+// AttrsFromContext 获取通过 ContextWithAttrs 存入上下文中的属性
+func AttrsFromContext(ctx context.Context) []Attr {
+	if ctx == nil {
+		return nil
+	}
+	attrs, _ := ctx.Value(contextAttrsKey{}).([]Attr)
+	return attrs
+}
+
+// resolveContextAttrs 将通过 ContextWithAttrs 存入上下文中的属性添加到日志记录中，并返回不再包含这些属性的上下文
+//   - 包装处理器应当在 Handle 的开始调用该函数，使上下文属性在脱敏、转换、过滤等处理前即已存在于日志记录中，并避免在后续的处理器中重复添加
+//   - 通过 ContextExtractor 提取的属性依赖于最终处理器的配置，因此仍然由最终处理器添加
+func resolveContextAttrs(ctx context.Context, record slog.Record) (context.Context, slog.Record) {
+	attrs := AttrsFromContext(ctx)
+	if len(attrs) == 0 {
+		return ctx, record
+	}
+	record = record.Clone()
+	record.AddAttrs(attrs...)
+	return context.WithValue(ctx, contextAttrsKey{}, []Attr(nil)), record
+}
+
+// withContextAttrs 将上下文中的属性及 extractors 所提取的属性添加到日志记录中，当存在属性时将返回日志记录的副本
+func withContextAttrs(ctx context.Context, record slog.Record, extractors []ContextExtractor) slog.Record {
+	if ctx == nil {
+		return record
+	}
+	attrs := AttrsFromContext(ctx)
+	for _, extractor := range extractors {
+		attrs = append(attrs[:len(attrs):len(attrs)], extractor(ctx)...)
+	}
+	if len(attrs) == 0 {
+		return record
+	}
+	record = record.Clone()
+	record.AddAttrs(attrs...)
+	return record
+}
@@ -0,0 +1,310 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+var _ DedupHandler = (*dedupHandler)(nil)
+
+// DedupOptions 是 DedupHandler 的选项
+type DedupOptions struct {
+	window     time.Duration       // 去重窗口
+	maxHold    time.Duration       // 连续模式下重复日志记录的最长保留时间
+	maxKeys    int                 // 窗口模式下最多跟踪的键数量
+	ignoreKeys map[string]struct{} // 计算键时忽略的易变属性
+}
+
+// NewDedupOptions 创建一个默认的 DedupOptions
+//   - 默认折叠连续的重复日志记录，重复日志记录最长保留 5 秒，计算键时忽略 time、request_id、trace_id 及 span_id 属性
+func NewDedupOptions() *DedupOptions {
+	return (&DedupOptions{
+		maxHold:    5 * time.Second,
+		maxKeys:    10000,
+		ignoreKeys: make(map[string]struct{}),
+	}).WithIgnoreKeys("time", "request_id", "trace_id", "span_id")
+}
+
+// WithWindow 设置去重窗口，当 window <= 0 时仅折叠连续的重复日志记录
+//   - 在窗口模式下，每个键在窗口内的第一条日志记录将被放行，其余的重复日志记录将在窗口结束时被折叠为一条摘要
+func (o *DedupOptions) WithWindow(window time.Duration) *DedupOptions {
+	o.window = window
+	return o
+}
+
+// WithMaxHold 设置连续模式下重复日志记录的最长保留时间，超过该时间时即使没有新的日志记录也会记录摘要
+func (o *DedupOptions) WithMaxHold(d time.Duration) *DedupOptions {
+	o.maxHold = d
+	return o
+}
+
+// WithMaxKeys 设置窗口模式下最多跟踪的键数量，超出时新的键将不会被去重
+func (o *DedupOptions) WithMaxKeys(max int) *DedupOptions {
+	o.maxKeys = max
+	return o
+}
+
+// WithIgnoreKeys 添加计算键时忽略的易变属性，它可以是属性键或以 "." 连接的完整路径
+func (o *DedupOptions) WithIgnoreKeys(keys ...string) *DedupOptions {
+	for _, key := range keys {
+		o.ignoreKeys[key] = struct{}{}
+	}
+	return o
+}
+
+// DedupHandler 是一个折叠重复日志记录的日志处理器，它以级别、分组、消息及归一化的属性作为键
+//   - 重复的日志记录将被保留，随后以一条 "last message repeated N times over D" 摘要代替，摘要包含首次及末次的时间
+type DedupHandler interface {
+	Handler
+
+	// Flush 立即为所有被保留的重复日志记录记录摘要
+	Flush()
+
+	// Close 记录所有摘要并停止后台协程
+	Close()
+}
+
+// NewDedupHandler 创建一个包装 handler 的去重日志处理器，当 options 为 nil 时将使用默认选项
+func NewDedupHandler(handler Handler, options *DedupOptions) DedupHandler {
+	if options == nil {
+		options = NewDedupOptions()
+	}
+	s := &dedupState{
+		window:     options.window,
+		maxHold:    options.maxHold,
+		maxKeys:    options.maxKeys,
+		ignoreKeys: options.ignoreKeys,
+		entries:    make(map[string]*dedupEntry),
+		done:       make(chan struct{}),
+	}
+
+	interval := s.window
+	if interval <= 0 {
+		interval = s.maxHold
+	}
+	if interval > 0 {
+		go s.run(max(interval/2, 10*time.Millisecond))
+	}
+	return &dedupHandler{state: s, handler: handler}
+}
+
+type dedupHandler struct {
+	state   *dedupState  // 去重状态，它在所有派生的处理器间共享
+	handler slog.Handler // 被包装的处理器
+	attrs   []string     // 通过 WithAttrs 累积的归一化属性
+	group   string       // 通过 WithGroup 构建的分组路径
+}
+
+func (h *dedupHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *dedupHandler) Handle(ctx context.Context, record slog.Record) error {
+	ctx = withCallerFrames(ctx)
+	ctx, record = resolveContextAttrs(ctx, record)
+	if !h.state.pass(ctx, h, record) {
+		return nil
+	}
+	return h.handler.Handle(ctx, record)
+}
+
+func (h *dedupHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	n := &dedupHandler{state: h.state, handler: h.handler.WithAttrs(attrs), attrs: slices.Clone(h.attrs), group: h.group}
+	for _, attr := range attrs {
+		n.attrs = h.state.normalize(n.attrs, "", attr)
+	}
+	return n
+}
+
+func (h *dedupHandler) WithGroup(name string) slog.Handler {
+	n := &dedupHandler{state: h.state, handler: h.handler.WithGroup(name), attrs: h.attrs, group: name}
+	if h.group != "" {
+		n.group = h.group + "." + name
+	}
+	return n
+}
+
+func (h *dedupHandler) Flush() {
+	h.state.flush(true)
+}
+
+func (h *dedupHandler) Close() {
+	h.state.close()
+}
+
+// key 计算日志记录的键
+func (h *dedupHandler) key(record slog.Record) string {
+	attrs := slices.Clone(h.attrs)
+	record.Attrs(func(attr slog.Attr) bool {
+		attrs = h.state.normalize(attrs, "", attr)
+		return true
+	})
+	slices.Sort(attrs)
+	return fmt.Sprintf("%d\x00%s\x00%s\x00%s", record.Level, h.group, record.Message, strings.Join(attrs, "\x00"))
+}
+
+type dedupEntry struct {
+	handler   slog.Handler    // 用于记录摘要的处理器
+	ctx       context.Context // 用于记录摘要的上下文，它是末条重复日志记录的上下文去除取消信号及调用者后的副本
+	level     slog.Level
+	msg       string
+	repeated  int       // 被保留的重复日志记录数量
+	first     time.Time // 首条重复日志记录的时间
+	last      time.Time // 末条重复日志记录的时间
+	expiresAt time.Time // 窗口结束时间或最长保留时间
+}
+
+type dedupState struct {
+	rw         sync.Mutex
+	window     time.Duration
+	maxHold    time.Duration
+	maxKeys    int
+	ignoreKeys map[string]struct{}
+	lastKey    string                 // 连续模式下上一条日志记录的键
+	entries    map[string]*dedupEntry // 连续模式下最多包含一个条目
+	done       chan struct{}
+	closeOnce  sync.Once
+}
+
+// normalize 将属性展开为 "路径=值" 的形式，并忽略易变属性
+func (s *dedupState) normalize(dst []string, prefix string, attr slog.Attr) []string {
+	key := attr.Key
+	if prefix != "" && key != "" {
+		key = prefix + "." + key
+	}
+	if _, ignore := s.ignoreKeys[attr.Key]; ignore && attr.Key != "" {
+		return dst
+	}
+	if _, ignore := s.ignoreKeys[key]; ignore && key != "" {
+		return dst
+	}
+
+	value := attr.Value.Resolve()
+	if value.Kind() == slog.KindGroup {
+		if key == "" {
+			key = prefix
+		}
+		for _, a := range value.Group() {
+			dst = s.normalize(dst, key, a)
+		}
+		return dst
+	}
+	return append(dst, key+"="+value.String())
+}
+
+// pass 判断日志记录是否应当被放行，重复的日志记录将被保留并计数
+func (s *dedupState) pass(ctx context.Context, h *dedupHandler, record slog.Record) bool {
+	now := record.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+	key := h.key(record)
+
+	s.rw.Lock()
+	var summaries []*dedupEntry
+	defer func() {
+		s.rw.Unlock()
+		s.report(summaries)
+	}()
+
+	if s.window <= 0 {
+		if key == s.lastKey {
+			entry, exist := s.entries[key]
+			if !exist {
+				entry = &dedupEntry{level: record.Level, msg: record.Message, first: now, expiresAt: now.Add(s.maxHold)}
+				s.entries[key] = entry
+			}
+			entry.handler = h.handler
+			entry.ctx = ctx
+			entry.repeated++
+			entry.last = now
+			return false
+		}
+		for k, entry := range s.entries {
+			summaries = append(summaries, entry)
+			delete(s.entries, k)
+		}
+		s.lastKey = key
+		return true
+	}
+
+	entry, exist := s.entries[key]
+	if exist && now.Before(entry.expiresAt) {
+		if entry.repeated == 0 {
+			entry.first = now
+		}
+		entry.handler = h.handler
+		entry.ctx = ctx
+		entry.repeated++
+		entry.last = now
+		return false
+	}
+	if exist {
+		if entry.repeated > 0 {
+			summaries = append(summaries, entry)
+		}
+		delete(s.entries, key)
+	}
+	if len(s.entries) < s.maxKeys {
+		s.entries[key] = &dedupEntry{level: record.Level, msg: record.Message, expiresAt: now.Add(s.window)}
+	}
+	return true
+}
+
+// flush 记录已到期或全部的摘要
+func (s *dedupState) flush(all bool) {
+	now := time.Now()
+	s.rw.Lock()
+	var summaries []*dedupEntry
+	for key, entry := range s.entries {
+		if !all && now.Before(entry.expiresAt) {
+			continue
+		}
+		if entry.repeated > 0 {
+			summaries = append(summaries, entry)
+		}
+		delete(s.entries, key)
+	}
+	s.rw.Unlock()
+	s.report(summaries)
+}
+
+func (s *dedupState) report(summaries []*dedupEntry) {
+	for _, entry := range summaries {
+		d := entry.last.Sub(entry.first)
+		record := slog.NewRecord(time.Now(), entry.level, fmt.Sprintf("last message repeated %d times over %s", entry.repeated, d), 0)
+		record.AddAttrs(
+			slog.String("repeated_msg", entry.msg),
+			slog.Int("repeated", entry.repeated),
+			slog.Time("first", entry.first),
+			slog.Time("last", entry.last),
+		)
+		// 摘要可能在原始请求结束后记录，因此去除取消信号，但保留强制级别等上下文值
+		_ = entry.handler.Handle(withoutCallerFrames(context.WithoutCancel(entry.ctx)), record)
+	}
+}
+
+func (s *dedupState) run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.flush(false)
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (s *dedupState) close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		s.flush(true)
+	})
+}
@@ -0,0 +1,131 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestDedupHandler tests that consecutive duplicates, ignoring volatile attributes, are folded into one summary.
+func TestDedupHandler(t *testing.T) {
+	var buf bytes.Buffer
+	dedup := NewDedupHandler(newHandler(GetConfigBuilder().Test().WithWriter(&buf)), NewDedupOptions().WithMaxHold(0))
+	defer dedup.Close()
+	logger := GetBuilder().FromHandler(dedup)
+
+	for i := 0; i < 4; i++ {
+		logger.Error("connection refused", "request_id", i)
+	}
+	logger.Info("done")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("output lines = %d, want 3: %s", len(lines), buf.String())
+	}
+	if !strings.Contains(lines[1], "repeated 3 times") || !strings.Contains(lines[2], "Done") {
+		t.Fatalf("unexpected output: %s", buf.String())
+	}
+	if strings.Contains(lines[1], "dedup_handler.go") {
+		t.Fatalf("summary contains handler caller: %s", lines[1])
+	}
+}
+
+// syncBuffer is a bytes.Buffer that is safe for the background flush of the handler.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// TestDedupHandlerModes tests window mode, the background max hold flush, the max keys limit
+// and that summaries keep the forced level of the folded records.
+func TestDedupHandlerModes(t *testing.T) {
+	handle := func(ctx context.Context, h DedupHandler, level slog.Level, msgs ...string) {
+		for _, msg := range msgs {
+			_ = h.Handle(ctx, slog.NewRecord(time.Now(), level, msg, 0))
+		}
+	}
+
+	for _, c := range []struct {
+		name    string
+		leveler Leveler
+		options *DedupOptions
+		run     func(h DedupHandler)
+		want    []string
+	}{
+		{
+			name:    "window",
+			options: NewDedupOptions().WithWindow(time.Hour),
+			run: func(h DedupHandler) {
+				handle(context.Background(), h, LevelInfo, "alpha", "beta", "alpha", "alpha")
+				h.Flush()
+			},
+			want: []string{"Alpha", "Beta", "repeated 2 times"},
+		},
+		{
+			name:    "max hold",
+			options: NewDedupOptions().WithMaxHold(20 * time.Millisecond),
+			run: func(h DedupHandler) {
+				handle(context.Background(), h, LevelInfo, "alpha", "alpha", "alpha")
+				// the summary is recorded in the background without further records
+				time.Sleep(200 * time.Millisecond)
+			},
+			want: []string{"Alpha", "repeated 2 times"},
+		},
+		{
+			name:    "max keys",
+			options: NewDedupOptions().WithWindow(time.Hour).WithMaxKeys(1),
+			run: func(h DedupHandler) {
+				handle(context.Background(), h, LevelInfo, "alpha", "beta", "beta", "alpha")
+				h.Flush()
+			},
+			want: []string{"Alpha", "Beta", "Beta", "repeated 1 times"},
+		},
+		{
+			name:    "forced level",
+			leveler: LevelInfo,
+			options: NewDedupOptions().WithMaxHold(0),
+			run: func(h DedupHandler) {
+				handle(WithForcedLevel(context.Background(), LevelDebug), h, LevelDebug, "alpha", "alpha", "alpha")
+				h.Flush()
+			},
+			want: []string{"Alpha", "repeated 2 times"},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			var buf syncBuffer
+			configuration := GetConfigBuilder().Test().WithWriter(&buf)
+			if c.leveler != nil {
+				configuration = configuration.WithLeveler(c.leveler)
+			}
+			dedup := NewDedupHandler(newHandler(configuration), c.options)
+			c.run(dedup)
+			dedup.Close()
+
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			if len(lines) != len(c.want) {
+				t.Fatalf("output lines = %d, want %d: %s", len(lines), len(c.want), buf.String())
+			}
+			for i, want := range c.want {
+				if !strings.Contains(lines[i], want) {
+					t.Fatalf("line %d %q misses %q", i, lines[i], want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,353 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// 加密流的格式如下，多个流可以直接拼接，例如进程重启后以追加模式打开同一个文件：
+//
+//	stream = header chunk*
+//	header = magic(8) version(1) streamID(16)
+//	chunk  = keyIDLen(1) keyID nonce(12) length(4) ciphertext(length)
+//
+// 每个块使用 AES-GCM 独立认证，附加数据为 streamID || seq(8) || keyID，其中 seq 为块在流中的序号，以防止块被重排或跨流替换
+const (
+	encryptMagic        = "GLOGENC1"
+	encryptVersion      = 1
+	encryptStreamIDSize = 16
+	encryptNonceSize    = 12
+	encryptMaxKeyID     = 64      // 密钥 ID 的最大长度，它保证块的首字节不会与流头部的魔数冲突
+	encryptMaxChunk     = 1 << 28 // 单个块的最大长度，超出该长度的块将被视为已损坏
+)
+
+var (
+	_ io.Writer = (*encryptWriter)(nil)
+	_ io.Reader = (*decryptReader)(nil)
+
+	// ErrTruncatedChunk 是加密流末尾存在不完整的块时返回的错误，在此之前的所有完整块均已被正常解密
+	ErrTruncatedChunk = errors.New("log encrypt: truncated trailing chunk")
+
+	// ErrUnknownKey 是解密时找不到块所使用的密钥时返回的错误
+	ErrUnknownKey = errors.New("log encrypt: unknown key id")
+
+	// ErrChunkAuthentication 是块认证失败时返回的错误，它表示块已被篡改或损坏
+	ErrChunkAuthentication = errors.New("log encrypt: chunk authentication failed")
+)
+
+// EncryptionOptions 是加密日志写入器及解密读取器的选项
+type EncryptionOptions struct {
+	keys      map[string][]byte // 密钥环
+	active    string            // 加密时使用的密钥 ID
+	chunkSize int               // 单个块的最大明文长度
+}
+
+// NewEncryptionOptions 创建一个默认的 EncryptionOptions，单个块的最大明文长度默认为 64KiB
+func NewEncryptionOptions() *EncryptionOptions {
+	return &EncryptionOptions{
+		keys:      make(map[string][]byte),
+		chunkSize: 64 << 10,
+	}
+}
+
+// WithKey 添加一个密钥并将其作为加密时使用的密钥，key 的长度必须为 16、24 或 32 字节
+//   - 在密钥轮换时，旧的密钥应通过 WithDecryptKey 保留，以便解密轮换前写入的日志
+func (o *EncryptionOptions) WithKey(id string, key []byte) *EncryptionOptions {
+	o.keys[id] = key
+	o.active = id
+	return o
+}
+
+// WithDecryptKey 添加一个仅用于解密的密钥
+func (o *EncryptionOptions) WithDecryptKey(id string, key []byte) *EncryptionOptions {
+	o.keys[id] = key
+	return o
+}
+
+// WithChunkSize 设置单个块的最大明文长度，较长的写入将被拆分为多个块
+func (o *EncryptionOptions) WithChunkSize(size int) *EncryptionOptions {
+	o.chunkSize = size
+	return o
+}
+
+func (o *EncryptionOptions) aead(id string) (cipher.AEAD, error) {
+	key, exist := o.keys[id]
+	if !exist {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, id)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// NewEncryptWriter 创建一个加密日志写入器，它会以认证块的形式将加密后的数据写入到 writer 中
+//   - 每一次 Write 至少会产生一个块，因此进程崩溃时最多只会丢失正在写入的块
+//   - 加密后的数据可以通过 NewDecryptReader 或 logdecrypt 命令解密
+func NewEncryptWriter(writer io.Writer, options *EncryptionOptions) (io.Writer, error) {
+	if options == nil || options.active == "" {
+		return nil, errors.New("log encrypt: no active key")
+	}
+	if len(options.active) > encryptMaxKeyID {
+		return nil, fmt.Errorf("log encrypt: key id longer than %d bytes", encryptMaxKeyID)
+	}
+	aead, err := options.aead(options.active)
+	if err != nil {
+		return nil, err
+	}
+	w := &encryptWriter{
+		writer:    writer,
+		aead:      aead,
+		keyID:     options.active,
+		chunkSize: options.chunkSize,
+	}
+	if w.chunkSize <= 0 || w.chunkSize > encryptMaxChunk-aead.Overhead() {
+		w.chunkSize = NewEncryptionOptions().chunkSize
+	}
+	if _, err = rand.Read(w.streamID[:]); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+type encryptWriter struct {
+	rw        sync.Mutex
+	writer    io.Writer                 // 日志写入器
+	aead      cipher.AEAD               // 加密器
+	keyID     string                    // 密钥 ID
+	chunkSize int                       // 单个块的最大明文长度
+	streamID  [encryptStreamIDSize]byte // 流 ID
+	seq       uint64                    // 下一个块的序号
+	started   bool                      // 是否已写入流头部
+	buf       bytes.Buffer              // 待写入的数据
+}
+
+func (w *encryptWriter) Write(p []byte) (n int, err error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	w.rw.Lock()
+	defer w.rw.Unlock()
+
+	w.buf.Reset()
+	if !w.started {
+		w.buf.WriteString(encryptMagic)
+		w.buf.WriteByte(encryptVersion)
+		w.buf.Write(w.streamID[:])
+	}
+
+	var nonce [encryptNonceSize]byte
+	var length [4]byte
+	seq := w.seq
+	for offset := 0; offset < len(p); offset += w.chunkSize {
+		chunk := p[offset:min(len(p), offset+w.chunkSize)]
+		if _, err = rand.Read(nonce[:]); err != nil {
+			return 0, err
+		}
+		ciphertext := w.aead.Seal(nil, nonce[:], chunk, encryptAdditionalData(w.streamID[:], seq, w.keyID))
+		binary.BigEndian.PutUint32(length[:], uint32(len(ciphertext)))
+
+		w.buf.WriteByte(byte(len(w.keyID)))
+		w.buf.WriteString(w.keyID)
+		w.buf.Write(nonce[:])
+		w.buf.Write(length[:])
+		w.buf.Write(ciphertext)
+		seq++
+	}
+
+	// 所有的块通过一次写入完成，以减少与其他写入交错的可能
+	written, err := w.writer.Write(w.buf.Bytes())
+	if err == nil && written < w.buf.Len() {
+		err = io.ErrShortWrite
+	}
+	if err != nil {
+		if written > 0 {
+			// 部分写入会在流中留下不完整的块，此后的写入将开始一个新的流，以便解密时能够从新的流头部处恢复
+			err = errors.Join(err, w.restart())
+		}
+		return 0, err
+	}
+	w.started = true
+	w.seq = seq
+	return len(p), nil
+}
+
+// restart 使用新的流 ID 开始一个新的流
+func (w *encryptWriter) restart() error {
+	w.started = false
+	w.seq = 0
+	_, err := rand.Read(w.streamID[:])
+	return err
+}
+
+func encryptAdditionalData(streamID []byte, seq uint64, keyID string) []byte {
+	ad := make([]byte, 0, len(streamID)+8+len(keyID))
+	ad = append(ad, streamID...)
+	ad = binary.BigEndian.AppendUint64(ad, seq)
+	return append(ad, keyID...)
+}
+
+// NewDecryptReader 创建一个解密读取器，它会以流的方式读取由 NewEncryptWriter 写入的数据并返回明文
+//   - 当末尾存在不完整的块时，将在返回所有完整块的明文后返回 ErrTruncatedChunk
+//   - 当不完整的块之后追加了新的流时，例如进程在写入时崩溃并在重启后继续追加，将从新的流头部处继续解密，并在读取结束后返回 ErrTruncatedChunk
+func NewDecryptReader(reader io.Reader, options *EncryptionOptions) io.Reader {
+	if options == nil {
+		options = NewEncryptionOptions()
+	}
+	return &decryptReader{
+		reader:  bufio.NewReader(reader),
+		options: options,
+		aeads:   make(map[string]cipher.AEAD),
+	}
+}
+
+type decryptReader struct {
+	reader    *bufio.Reader
+	options   *EncryptionOptions
+	aeads     map[string]cipher.AEAD    // 已创建的解密器
+	streamID  [encryptStreamIDSize]byte // 当前流 ID
+	seq       uint64                    // 下一个块的序号
+	started   bool                      // 是否已读取流头部
+	plaintext []byte                    // 尚未被读取的明文
+	offset    int64                     // 已读取的密文字节数
+	torn      bool                      // 是否跳过了不完整的块
+	err       error                     // 终止错误
+}
+
+func (r *decryptReader) Read(p []byte) (n int, err error) {
+	for len(r.plaintext) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		r.err = r.next()
+	}
+	n = copy(p, r.plaintext)
+	r.plaintext = r.plaintext[n:]
+	return n, nil
+}
+
+// next 读取下一个块，如果遇到流头部则开始一个新的流
+func (r *decryptReader) next() error {
+	first, err := r.reader.Peek(1)
+	if err != nil {
+		if err == io.EOF && r.started {
+			if r.torn {
+				return ErrTruncatedChunk
+			}
+			return io.EOF
+		}
+		return r.truncated(err)
+	}
+
+	if !r.started || first[0] == encryptMagic[0] {
+		return r.header()
+	}
+
+	start := r.offset
+	keyIDLen, _ := r.reader.ReadByte()
+	r.offset++
+	head := make([]byte, 1+int(keyIDLen)+encryptNonceSize+4)
+	head[0] = keyIDLen
+	if n, err := r.readFull(head[1:]); err != nil {
+		return r.resync(head[:1+n], start, r.truncated(err))
+	}
+	keyID := string(head[1 : 1+keyIDLen])
+	nonce := head[1+keyIDLen : len(head)-4]
+	length := binary.BigEndian.Uint32(head[len(head)-4:])
+	if length > encryptMaxChunk {
+		return r.resync(head, start, fmt.Errorf("%w: chunk %d at offset %d has invalid length", ErrChunkAuthentication, r.seq, start))
+	}
+	chunk := make([]byte, len(head)+int(length))
+	copy(chunk, head)
+	ciphertext := chunk[len(head):]
+	if n, err := r.readFull(ciphertext); err != nil {
+		return r.resync(chunk[:len(head)+n], start, r.truncated(err))
+	}
+
+	aead, err := r.aead(keyID)
+	if err != nil {
+		return r.resync(chunk, start, err)
+	}
+	plaintext, err := aead.Open(nil, nonce, ciphertext, encryptAdditionalData(r.streamID[:], r.seq, keyID))
+	if err != nil {
+		return r.resync(chunk, start, fmt.Errorf("%w: chunk %d at offset %d", ErrChunkAuthentication, r.seq, start))
+	}
+	r.seq++
+	r.plaintext = plaintext
+	return nil
+}
+
+// resync 在块无法被解密时检查其中是否包含新的流头部，如果包含则表示该块在写入时被截断，并在其后追加了新的流
+//   - 此时将从新的流头部处继续读取，否则返回 err
+func (r *decryptReader) resync(chunk []byte, start int64, err error) error {
+	i := bytes.Index(chunk, []byte(encryptMagic))
+	for j := max(len(chunk)-len(encryptMagic)+1, 0); i < 0 && j < len(chunk); j++ {
+		// 新的流头部可能跨越块的末尾
+		rest, _ := r.reader.Peek(len(encryptMagic) - (len(chunk) - j))
+		if string(chunk[j:])+string(rest) == encryptMagic {
+			i = j
+		}
+	}
+	if i < 0 {
+		return err
+	}
+	r.reader = bufio.NewReader(io.MultiReader(bytes.NewReader(chunk[i:]), r.reader))
+	r.offset = start + int64(i)
+	r.torn = true
+	return nil
+}
+
+func (r *decryptReader) header() error {
+	header := make([]byte, len(encryptMagic)+1+encryptStreamIDSize)
+	if _, err := r.readFull(header); err != nil {
+		return r.truncated(err)
+	}
+	if string(header[:len(encryptMagic)]) != encryptMagic {
+		return fmt.Errorf("log encrypt: invalid stream header at offset %d", r.offset-int64(len(header)))
+	}
+	if header[len(encryptMagic)] != encryptVersion {
+		return fmt.Errorf("log encrypt: unsupported version %d", header[len(encryptMagic)])
+	}
+	copy(r.streamID[:], header[len(encryptMagic)+1:])
+	r.seq = 0
+	r.started = true
+	return nil
+}
+
+func (r *decryptReader) readFull(p []byte) (int, error) {
+	n, err := io.ReadFull(r.reader, p)
+	r.offset += int64(n)
+	return n, err
+}
+
+func (r *decryptReader) truncated(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		if !r.started && r.offset == 0 {
+			return io.EOF
+		}
+		return ErrTruncatedChunk
+	}
+	return err
+}
+
+func (r *decryptReader) aead(keyID string) (cipher.AEAD, error) {
+	if aead, exist := r.aeads[keyID]; exist {
+		return aead, nil
+	}
+	aead, err := r.options.aead(keyID)
+	if err != nil {
+		return nil, err
+	}
+	r.aeads[keyID] = aead
+	return aead, nil
+}
@@ -0,0 +1,124 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+// TestEncryptWriter tests that encrypted streams round trip across key rotation and concatenated streams.
+func TestEncryptWriter(t *testing.T) {
+	var buf bytes.Buffer
+	oldKey, newKey := bytes.Repeat([]byte{1}, 32), bytes.Repeat([]byte{2}, 32)
+
+	w, err := NewEncryptWriter(&buf, NewEncryptionOptions().WithKey("k1", oldKey).WithChunkSize(4))
+	if err != nil {
+		t.Fatalf("NewEncryptWriter() error = %v", err)
+	}
+	_, _ = w.Write([]byte("first record\n"))
+
+	// 轮换密钥后以新的流继续追加
+	w, _ = NewEncryptWriter(&buf, NewEncryptionOptions().WithKey("k2", newKey))
+	_, _ = w.Write([]byte("second record\n"))
+
+	if bytes.Contains(buf.Bytes(), []byte("record")) {
+		t.Fatal("plaintext found in encrypted stream")
+	}
+
+	options := NewEncryptionOptions().WithDecryptKey("k1", oldKey).WithDecryptKey("k2", newKey)
+	plaintext, err := io.ReadAll(NewDecryptReader(bytes.NewReader(buf.Bytes()), options))
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	if string(plaintext) != "first record\nsecond record\n" {
+		t.Fatalf("unexpected plaintext: %q", plaintext)
+	}
+
+	_, err = io.ReadAll(NewDecryptReader(bytes.NewReader(buf.Bytes()), NewEncryptionOptions().WithDecryptKey("k1", oldKey)))
+	if !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("unknown key error = %v", err)
+	}
+}
+
+// TestDecryptReaderTruncated tests that a truncated trailing chunk is reported after all complete chunks.
+func TestDecryptReaderTruncated(t *testing.T) {
+	var buf bytes.Buffer
+	options := NewEncryptionOptions().WithKey("k1", bytes.Repeat([]byte{1}, 16))
+	w, _ := NewEncryptWriter(&buf, options)
+	_, _ = w.Write([]byte("complete\n"))
+	_, _ = w.Write([]byte("truncated\n"))
+
+	data := buf.Bytes()[:buf.Len()-5]
+	plaintext, err := io.ReadAll(NewDecryptReader(bytes.NewReader(data), options))
+	if !errors.Is(err, ErrTruncatedChunk) {
+		t.Fatalf("ReadAll() error = %v, want ErrTruncatedChunk", err)
+	}
+	if string(plaintext) != "complete\n" {
+		t.Fatalf("unexpected plaintext: %q", plaintext)
+	}
+
+	data = bytes.Clone(buf.Bytes())
+	data[len(data)-1] ^= 0xff
+	_, err = io.ReadAll(NewDecryptReader(bytes.NewReader(data), options))
+	if !errors.Is(err, ErrChunkAuthentication) {
+		t.Fatalf("ReadAll() error = %v, want ErrChunkAuthentication", err)
+	}
+}
+
+// TestDecryptReaderTornChunk tests that a torn chunk followed by an appended stream is reported as truncated
+// and the appended stream is still decrypted.
+func TestDecryptReaderTornChunk(t *testing.T) {
+	var buf bytes.Buffer
+	options := NewEncryptionOptions().WithKey("k1", bytes.Repeat([]byte{1}, 16))
+	w, _ := NewEncryptWriter(&buf, options)
+	_, _ = w.Write([]byte("complete\n"))
+	_, _ = w.Write([]byte("torn\n"))
+	buf.Truncate(buf.Len() - 5)
+
+	w, _ = NewEncryptWriter(&buf, options)
+	_, _ = w.Write([]byte("appended\n"))
+
+	plaintext, err := io.ReadAll(NewDecryptReader(bytes.NewReader(buf.Bytes()), options))
+	if !errors.Is(err, ErrTruncatedChunk) {
+		t.Fatalf("ReadAll() error = %v, want ErrTruncatedChunk", err)
+	}
+	if string(plaintext) != "complete\nappended\n" {
+		t.Fatalf("unexpected plaintext: %q", plaintext)
+	}
+}
+
+type shortWriter struct {
+	bytes.Buffer
+	short bool
+}
+
+func (w *shortWriter) Write(p []byte) (int, error) {
+	if w.short {
+		w.short = false
+		return w.Buffer.Write(p[:len(p)/2])
+	}
+	return w.Buffer.Write(p)
+}
+
+// TestEncryptWriterShortWrite tests that a partial write is reported and the following writes start a new stream.
+func TestEncryptWriterShortWrite(t *testing.T) {
+	var buf shortWriter
+	options := NewEncryptionOptions().WithKey("k1", bytes.Repeat([]byte{1}, 16))
+	w, _ := NewEncryptWriter(&buf, options)
+	_, _ = w.Write([]byte("first\n"))
+
+	buf.short = true
+	if _, err := w.Write([]byte("partial\n")); !errors.Is(err, io.ErrShortWrite) {
+		t.Fatalf("Write() error = %v, want io.ErrShortWrite", err)
+	}
+	_, _ = w.Write([]byte("last\n"))
+
+	plaintext, err := io.ReadAll(NewDecryptReader(bytes.NewReader(buf.Bytes()), options))
+	if !errors.Is(err, ErrTruncatedChunk) {
+		t.Fatalf("ReadAll() error = %v, want ErrTruncatedChunk", err)
+	}
+	if string(plaintext) != "first\nlast\n" {
+		t.Fatalf("unexpected plaintext: %q", plaintext)
+	}
+}
@@ -0,0 +1,183 @@
+package log
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+// EnrichField 是静态元数据的字段，它同时也是该字段作为属性时的键
+type EnrichField string
+
+const (
+	EnrichHost         EnrichField = "host"          // 主机名
+	EnrichPID          EnrichField = "pid"           // 进程 ID
+	EnrichService      EnrichField = "service"       // 服务名称
+	EnrichVersion      EnrichField = "version"       // 服务版本
+	EnrichCommit       EnrichField = "commit"        // 构建时的 Git 提交
+	EnrichGoVersion    EnrichField = "go_version"    // 构建时的 Go 版本
+	EnrichContainerID  EnrichField = "container_id"  // 容器 ID
+	EnrichK8sNamespace EnrichField = "k8s_namespace" // Kubernetes 命名空间
+	EnrichK8sPod       EnrichField = "k8s_pod"       // Kubernetes Pod 名称
+	EnrichK8sNode      EnrichField = "k8s_node"      // Kubernetes 节点名称
+)
+
+// enrichFields 是所有的字段，它决定了属性的顺序
+var enrichFields = []EnrichField{
+	EnrichHost, EnrichPID, EnrichService, EnrichVersion, EnrichCommit, EnrichGoVersion,
+	EnrichContainerID, EnrichK8sNamespace, EnrichK8sPod, EnrichK8sNode,
+}
+
+// Enrichment 是主机、进程、构建及运行时的静态元数据，它通过 LoggerConfiguration.WithEnrichment 作为固定属性添加到日志记录中
+//   - 值为空的字段不会被添加到日志记录中
+//   - 对于不使用 LoggerConfiguration 的处理器，例如 WebhookHandler 或 slog.JSONHandler，可以通过 Attrs 配合 WithAttrs 或 Logger.With 添加
+type Enrichment struct {
+	Host         string
+	PID          int
+	Service      string
+	Version      string
+	Commit       string
+	GoVersion    string
+	ContainerID  string
+	K8sNamespace string
+	K8sPod       string
+	K8sNode      string
+}
+
+var collectEnrichment = sync.OnceValue(func() Enrichment {
+	e := Enrichment{
+		PID:       os.Getpid(),
+		GoVersion: runtime.Version(),
+	}
+	e.Host, _ = os.Hostname()
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if info.Main.Version != "(devel)" {
+			e.Version = info.Main.Version
+		}
+		var modified bool
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				e.Commit = setting.Value
+			case "vcs.modified":
+				modified = setting.Value == "true"
+			}
+		}
+		if modified && e.Commit != "" {
+			e.Commit += "-dirty"
+		}
+	}
+	if len(os.Args) > 0 {
+		e.Service = filepath.Base(os.Args[0])
+	}
+	e.ContainerID = readContainerID()
+	return e.FromEnv()
+})
+
+// CollectEnrichment 获取当前进程的静态元数据，它们仅在首次调用时被收集
+//   - 主机名、进程 ID 及 Go 版本来自运行时，服务版本及 Git 提交来自 debug.ReadBuildInfo，服务名称默认为可执行文件名称
+//   - 容器 ID 来自 /proc/self/cgroup 及 /proc/self/mountinfo，仅在 Linux 容器中可用
+//   - 最后将通过 Enrichment.FromEnv 读取环境变量中的值
+func CollectEnrichment() Enrichment {
+	return collectEnrichment()
+}
+
+// FromEnv 返回以环境变量中的值覆盖后的副本，未设置的环境变量将保持原有的值
+//   - 服务名称：SERVICE_NAME、OTEL_SERVICE_NAME
+//   - 服务版本：SERVICE_VERSION、APP_VERSION
+//   - 容器 ID：CONTAINER_ID
+//   - Kubernetes Downward API：POD_NAMESPACE、POD_NAME、NODE_NAME，或带有 K8S_ 前缀的同名变量
+func (e Enrichment) FromEnv() Enrichment {
+	lookup := func(target *string, keys ...string) {
+		for _, key := range keys {
+			if value := os.Getenv(key); value != "" {
+				*target = value
+				return
+			}
+		}
+	}
+	lookup(&e.Service, "SERVICE_NAME", "OTEL_SERVICE_NAME")
+	lookup(&e.Version, "SERVICE_VERSION", "APP_VERSION")
+	lookup(&e.ContainerID, "CONTAINER_ID")
+	lookup(&e.K8sNamespace, "POD_NAMESPACE", "K8S_POD_NAMESPACE", "K8S_NAMESPACE")
+	lookup(&e.K8sPod, "POD_NAME", "K8S_POD_NAME")
+	lookup(&e.K8sNode, "NODE_NAME", "K8S_NODE_NAME")
+	return e
+}
+
+// Attrs 获取指定字段的属性，当 fields 为空时将获取所有字段的属性，值为空的字段将被忽略
+//   - 返回的属性可以直接传递给任意 slog.Handler 的 WithAttrs，例如 handler.WithAttrs(CollectEnrichment().Attrs())
+func (e Enrichment) Attrs(fields ...EnrichField) []Attr {
+	if len(fields) == 0 {
+		fields = enrichFields
+	}
+	attrs := make([]Attr, 0, len(fields))
+	for _, field := range fields {
+		switch field {
+		case EnrichPID:
+			if e.PID != 0 {
+				attrs = append(attrs, Int(string(field), e.PID))
+			}
+		default:
+			if value := e.value(field); value != "" {
+				attrs = append(attrs, String(string(field), value))
+			}
+		}
+	}
+	return attrs
+}
+
+func (e Enrichment) value(field EnrichField) string {
+	switch field {
+	case EnrichHost:
+		return e.Host
+	case EnrichService:
+		return e.Service
+	case EnrichVersion:
+		return e.Version
+	case EnrichCommit:
+		return e.Commit
+	case EnrichGoVersion:
+		return e.GoVersion
+	case EnrichContainerID:
+		return e.ContainerID
+	case EnrichK8sNamespace:
+		return e.K8sNamespace
+	case EnrichK8sPod:
+		return e.K8sPod
+	case EnrichK8sNode:
+		return e.K8sNode
+	}
+	return ""
+}
+
+var containerIDPattern = regexp.MustCompile(`[0-9a-f]{64}`)
+
+// readContainerID 从 cgroup 或挂载信息中读取容器 ID，当不在容器中时返回空字符串
+func readContainerID() string {
+	for _, path := range []string{"/proc/self/cgroup", "/proc/self/mountinfo"} {
+		f, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := scanner.Text()
+			// mountinfo 中包含宿主机上其他容器的路径，仅信任容器运行时为当前容器挂载的文件
+			if path == "/proc/self/mountinfo" && !strings.Contains(line, "/containers/") {
+				continue
+			}
+			if id := containerIDPattern.FindString(line); id != "" {
+				_ = f.Close()
+				return id
+			}
+		}
+		_ = f.Close()
+	}
+	return ""
+}
@@ -0,0 +1,53 @@
+package log
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestEnrichment tests that selected metadata fields are attached as fixed attrs and can be overridden by environment variables.
+func TestEnrichment(t *testing.T) {
+	t.Setenv("SERVICE_NAME", "checkout")
+	t.Setenv("POD_NAMESPACE", "shop")
+
+	enrichment := CollectEnrichment().FromEnv()
+	if enrichment.PID != os.Getpid() || enrichment.Service != "checkout" || enrichment.K8sNamespace != "shop" {
+		t.Fatalf("unexpected enrichment: %+v", enrichment)
+	}
+
+	var buf bytes.Buffer
+	config := GetConfigBuilder().Test().WithWriter(&buf).WithEnrichment(enrichment, EnrichService, EnrichK8sNamespace, EnrichK8sPod)
+	GetBuilder().FromConfiguration(config).With("request", 1).Info("enriched")
+
+	out := buf.String()
+	if !strings.Contains(out, `"checkout"`) || !strings.Contains(out, `"shop"`) || strings.Contains(out, "k8s_pod") || strings.Contains(out, "pid") {
+		t.Fatalf("unexpected output: %s", out)
+	}
+	if strings.Index(out, "service") > strings.Index(out, "request") {
+		t.Fatalf("enrichment attrs are not placed before other attrs: %s", out)
+	}
+}
+
+// TestEnrichmentLive tests that enrichment configured after a logger is built applies to it,
+// and that the attrs can be attached to handlers without a LoggerConfiguration.
+func TestEnrichmentLive(t *testing.T) {
+	enrichment := Enrichment{Service: "checkout", PID: 42}
+
+	var buf bytes.Buffer
+	config := GetConfigBuilder().Test().WithWriter(&buf)
+	logger := GetBuilder().FromConfiguration(config).With("request", 1)
+	config.WithEnrichment(enrichment, EnrichService)
+	logger.Info("enriched")
+	if out := buf.String(); !strings.Contains(out, `"checkout"`) {
+		t.Fatalf("enrichment does not apply to a built logger: %s", out)
+	}
+
+	var jsonBuf bytes.Buffer
+	slog.New(slog.NewJSONHandler(&jsonBuf, nil).WithAttrs(enrichment.Attrs())).Info("enriched")
+	if out := jsonBuf.String(); !strings.Contains(out, `"service":"checkout"`) || !strings.Contains(out, `"pid":42`) {
+		t.Fatalf("enrichment attrs are not attached to the JSON handler: %s", out)
+	}
+}
@@ -0,0 +1,251 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var _ FailoverHandler = (*failoverHandler)(nil)
+
+// ErrFailoverTimeout 是日志目标写入超时时返回的错误
+var ErrFailoverTimeout = errors.New("log failover destination timeout")
+
+// ErrFailoverBusy 是日志目标仍在处理上一次超时的日志记录时返回的错误
+var ErrFailoverBusy = errors.New("log failover destination busy")
+
+// FailoverOptions 是 FailoverHandler 的选项
+type FailoverOptions struct {
+	timeout       time.Duration // 单个目标的处理超时时间
+	probeInterval time.Duration // 探测首选目标的间隔
+}
+
+// NewFailoverOptions 创建一个默认的 FailoverOptions
+//   - 默认不限制处理超时时间，每 30 秒探测一次首选目标
+func NewFailoverOptions() *FailoverOptions {
+	return &FailoverOptions{
+		probeInterval: 30 * time.Second,
+	}
+}
+
+// WithTimeout 设置单个目标的处理超时时间，当 timeout <= 0 时表示不限制
+//   - 超时的处理不会被中断，它可能在切换后仍然完成写入
+//   - 当目标存在已超时但仍未完成的处理时，在其完成前该目标将被视为失败并跳过，未超时的并发处理不受影响
+func (o *FailoverOptions) WithTimeout(timeout time.Duration) *FailoverOptions {
+	o.timeout = timeout
+	return o
+}
+
+// WithProbeInterval 设置探测首选目标的间隔，在切换到后备目标后，每隔该间隔会优先尝试首选目标
+func (o *FailoverOptions) WithProbeInterval(interval time.Duration) *FailoverOptions {
+	o.probeInterval = interval
+	return o
+}
+
+// FailoverHandler 是一个故障转移日志处理器，它会按顺序将日志记录交给第一个可用的目标
+//   - 当目标返回错误、发生 panic 或处理超时时，将切换到下一个目标，并在新的目标上记录切换事件
+//   - 在使用后备目标期间，将周期性的探测首选目标，当其恢复时切换回首选目标
+type FailoverHandler interface {
+	Handler
+
+	// Active 获取当前正在使用的目标索引
+	Active() int
+}
+
+// NewFailoverHandler 创建一个故障转移日志处理器，handlers 的顺序即为目标的优先级，当 options 为 nil 时将使用默认选项
+func NewFailoverHandler(options *FailoverOptions, handlers ...Handler) FailoverHandler {
+	if options == nil {
+		options = NewFailoverOptions()
+	}
+	slogHandlers := make([]slog.Handler, len(handlers))
+	for i, h := range handlers {
+		slogHandlers[i] = h
+	}
+	return &failoverHandler{
+		state: &failoverState{
+			handlers:      slogHandlers,
+			stuck:         make([]atomic.Int32, len(slogHandlers)),
+			timeout:       options.timeout,
+			probeInterval: options.probeInterval,
+		},
+		handlers: slogHandlers,
+	}
+}
+
+type failoverHandler struct {
+	state    *failoverState // 故障转移状态，它在所有派生的处理器间共享
+	handlers []slog.Handler // 当前处理器所使用的目标
+}
+
+func (h *failoverHandler) Active() int {
+	active, _ := h.state.route(false)
+	return active
+}
+
+func (h *failoverHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for i := range h.handlers {
+		if h.handlers[i].Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *failoverHandler) Handle(ctx context.Context, record slog.Record) error {
+	ctx = withCallerFrames(ctx)
+	ctx, record = resolveContextAttrs(ctx, record)
+	active, probe := h.state.route(true)
+
+	var errs []error
+	for _, i := range h.state.candidates(active, probe) {
+		if !h.handlers[i].Enabled(ctx, record.Level) {
+			continue
+		}
+		err := h.state.handle(ctx, i, h.handlers[i], record)
+		if err == nil {
+			if len(errs) > 0 || i < active {
+				// 仅在更高优先级的目标失败或恢复时切换，未启用的目标不会导致切换
+				h.state.switchTo(active, i, errors.Join(errs...))
+			}
+			return nil
+		}
+		errs = append(errs, fmt.Errorf("log failover destination %d: %w", i, err))
+	}
+	return errors.Join(errs...)
+}
+
+func (h *failoverHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, s := range h.handlers {
+		handlers[i] = s.WithAttrs(attrs)
+	}
+	return &failoverHandler{state: h.state, handlers: handlers}
+}
+
+func (h *failoverHandler) WithGroup(name string) slog.Handler {
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, s := range h.handlers {
+		handlers[i] = s.WithGroup(name)
+	}
+	return &failoverHandler{state: h.state, handlers: handlers}
+}
+
+type failoverState struct {
+	rw            sync.Mutex
+	handlers      []slog.Handler // 原始目标，用于记录切换事件
+	stuck         []atomic.Int32 // 每个目标已超时但仍未完成的处理数量
+	timeout       time.Duration  // 单个目标的处理超时时间
+	probeInterval time.Duration  // 探测首选目标的间隔
+	active        int            // 当前正在使用的目标索引
+	lastProbe     time.Time      // 上一次探测首选目标的时间
+}
+
+// route 获取当前正在使用的目标，以及是否需要探测首选目标
+func (s *failoverState) route(probe bool) (active int, doProbe bool) {
+	s.rw.Lock()
+	defer s.rw.Unlock()
+	if probe && s.active > 0 && s.probeInterval > 0 && time.Since(s.lastProbe) >= s.probeInterval {
+		s.lastProbe = time.Now()
+		doProbe = true
+	}
+	return s.active, doProbe
+}
+
+// candidates 获取本次处理的目标尝试顺序，它从当前目标开始向后尝试，最后回绕到更高优先级的目标
+func (s *failoverState) candidates(active int, probe bool) []int {
+	candidates := make([]int, 0, len(s.handlers))
+	if probe {
+		candidates = append(candidates, 0)
+	}
+	for i := active; i < len(s.handlers); i++ {
+		candidates = append(candidates, i)
+	}
+	for i := 0; i < active; i++ {
+		if probe && i == 0 {
+			continue
+		}
+		candidates = append(candidates, i)
+	}
+	return candidates
+}
+
+// handle 在超时限制下使用第 i 个目标处理日志记录，并将 panic 转换为错误
+//   - 当该目标仍存在超时未完成的处理时，将直接返回 ErrFailoverBusy
+func (s *failoverState) handle(ctx context.Context, i int, handler slog.Handler, record slog.Record) error {
+	if s.timeout <= 0 {
+		return failoverHandle(ctx, handler, record)
+	}
+	if s.stuck[i].Load() > 0 {
+		return ErrFailoverBusy
+	}
+
+	// abandoned 由处理完成及超时中先发生的一方设置，超时的一方负责标记目标为卡住，完成的一方负责清除标记
+	var abandoned atomic.Int32
+	done := make(chan error, 1)
+	go func() {
+		err := failoverHandle(ctx, handler, record.Clone())
+		if !abandoned.CompareAndSwap(0, 1) {
+			s.stuck[i].Add(-1)
+		}
+		done <- err
+	}()
+
+	timer := time.NewTimer(s.timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		if abandoned.CompareAndSwap(0, 2) {
+			s.stuck[i].Add(1)
+			return ErrFailoverTimeout
+		}
+		return <-done
+	}
+}
+
+func failoverHandle(ctx context.Context, handler slog.Handler, record slog.Record) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			switch v := v.(type) {
+			case error:
+				err = v
+			default:
+				err = fmt.Errorf("recover from panic: %v", v)
+			}
+		}
+	}()
+	return handler.Handle(ctx, record)
+}
+
+// switchTo 切换到指定的目标，并在该目标上记录切换事件
+//   - 切换事件与触发切换的日志记录无关，因此不使用其上下文，避免继承其调用栈及上下文属性
+func (s *failoverState) switchTo(from, to int, cause error) {
+	if from == to {
+		return
+	}
+	s.rw.Lock()
+	if s.active != from {
+		// 已经被其他的处理切换
+		s.rw.Unlock()
+		return
+	}
+	s.active = to
+	s.lastProbe = time.Now()
+	s.rw.Unlock()
+
+	level, msg := LevelWarn, "log destination failover"
+	if to < from {
+		level, msg = LevelInfo, "log destination recovered"
+	}
+	record := slog.NewRecord(time.Now(), level, msg, 0)
+	record.AddAttrs(slog.Int("from", from), slog.Int("to", to))
+	if cause != nil {
+		record.AddAttrs(slog.String("cause", cause.Error()))
+	}
+	_ = failoverHandle(withoutCallerFrames(context.Background()), s.handlers[to], record)
+}
@@ -0,0 +1,195 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type failoverTestHandler struct {
+	slog.Handler
+	level    slog.Level
+	calls    atomic.Int64
+	mu       sync.Mutex
+	handle   func(ctx context.Context) error
+	messages []string
+}
+
+func newFailoverTestHandler(handle func(ctx context.Context) error) *failoverTestHandler {
+	return &failoverTestHandler{Handler: newSilentHandler(), level: LevelDebug, handle: handle}
+}
+
+func (h *failoverTestHandler) setHandle(handle func(ctx context.Context) error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.handle = handle
+}
+
+func (h *failoverTestHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *failoverTestHandler) Handle(ctx context.Context, record slog.Record) error {
+	h.calls.Add(1)
+	h.mu.Lock()
+	handle := h.handle
+	h.mu.Unlock()
+	if handle != nil {
+		if err := handle(ctx); err != nil {
+			return err
+		}
+	}
+
+	message := record.Message
+	record.Attrs(func(attr slog.Attr) bool {
+		if attr.Key == "cause" {
+			message += " cause=" + attr.Value.String()
+		}
+		return true
+	})
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.messages = append(h.messages, message)
+	return nil
+}
+
+func (h *failoverTestHandler) Messages() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), h.messages...)
+}
+
+// TestFailoverHandler tests that errors, panics and timeouts switch to the next destination and record a switch event there.
+func TestFailoverHandler(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	cases := []struct {
+		name   string
+		handle func(ctx context.Context) error
+		cause  string
+	}{
+		{name: "error", cause: "broken", handle: func(context.Context) error {
+			return errors.New("broken")
+		}},
+		{name: "panic", cause: "recover from panic: boom", handle: func(context.Context) error {
+			panic("boom")
+		}},
+		{name: "timeout", cause: ErrFailoverTimeout.Error(), handle: func(context.Context) error {
+			<-release
+			return nil
+		}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			primary, secondary := newFailoverTestHandler(c.handle), newFailoverTestHandler(nil)
+			handler := NewFailoverHandler(NewFailoverOptions().WithTimeout(20*time.Millisecond), primary, secondary)
+
+			if err := handler.Handle(context.Background(), slog.NewRecord(time.Now(), LevelInfo, "first", 0)); err != nil {
+				t.Fatalf("Handle() error = %v", err)
+			}
+			if handler.Active() != 1 {
+				t.Fatalf("Active() = %d, want 1", handler.Active())
+			}
+			messages := secondary.Messages()
+			if len(messages) != 2 || messages[0] != "first" || !strings.HasPrefix(messages[1], "log destination failover") || !strings.Contains(messages[1], c.cause) {
+				t.Fatalf("unexpected messages on the secondary destination: %q", messages)
+			}
+		})
+	}
+}
+
+// TestFailoverHandlerRecovery tests that the primary destination is probed and switched back to once it recovers.
+func TestFailoverHandlerRecovery(t *testing.T) {
+	primary := newFailoverTestHandler(func(context.Context) error {
+		return errors.New("broken")
+	})
+	secondary := newFailoverTestHandler(nil)
+	handler := NewFailoverHandler(NewFailoverOptions().WithProbeInterval(time.Millisecond), primary, secondary)
+
+	_ = handler.Handle(context.Background(), slog.NewRecord(time.Now(), LevelInfo, "first", 0))
+	if handler.Active() != 1 {
+		t.Fatalf("Active() = %d, want 1", handler.Active())
+	}
+
+	primary.setHandle(nil)
+	time.Sleep(5 * time.Millisecond)
+	if err := handler.Handle(context.Background(), slog.NewRecord(time.Now(), LevelInfo, "second", 0)); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	if handler.Active() != 0 {
+		t.Fatalf("Active() = %d, want 0", handler.Active())
+	}
+	messages := primary.Messages()
+	if len(messages) != 2 || messages[0] != "second" || messages[1] != "log destination recovered" {
+		t.Fatalf("unexpected messages on the primary destination: %q", messages)
+	}
+}
+
+// TestFailoverHandlerSkip tests that disabled and busy destinations are skipped instead of ending or blocking the handling.
+func TestFailoverHandlerSkip(t *testing.T) {
+	disabled, enabled := newFailoverTestHandler(nil), newFailoverTestHandler(nil)
+	disabled.level = LevelError
+	handler := NewFailoverHandler(nil, disabled, enabled)
+	if err := handler.Handle(context.Background(), slog.NewRecord(time.Now(), LevelInfo, "record", 0)); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	if messages := enabled.Messages(); handler.Active() != 0 || len(messages) != 1 || messages[0] != "record" {
+		t.Fatalf("disabled destination is not skipped without switching: active = %d, messages = %q", handler.Active(), messages)
+	}
+	if err := NewFailoverHandler(nil, disabled).Handle(context.Background(), slog.NewRecord(time.Now(), LevelInfo, "record", 0)); err != nil {
+		t.Fatalf("Handle() without an enabled destination error = %v", err)
+	}
+
+	release := make(chan struct{})
+	defer close(release)
+	stuck := newFailoverTestHandler(func(context.Context) error {
+		<-release
+		return nil
+	})
+	fallback := newFailoverTestHandler(nil)
+	handler = NewFailoverHandler(NewFailoverOptions().WithTimeout(20*time.Millisecond).WithProbeInterval(time.Nanosecond), stuck, fallback)
+	for i := 0; i < 5; i++ {
+		if err := handler.Handle(context.Background(), slog.NewRecord(time.Now(), LevelInfo, "record", 0)); err != nil {
+			t.Fatalf("Handle() error = %v", err)
+		}
+	}
+	if calls := stuck.calls.Load(); calls != 1 {
+		t.Fatalf("busy destination was called %d times, want 1", calls)
+	}
+	if got := len(fallback.Messages()); got != 6 {
+		t.Fatalf("fallback destination got %d records, want 6", got)
+	}
+}
+
+// TestFailoverHandlerConcurrent tests that concurrent records on a healthy destination within the timeout do not fail over.
+func TestFailoverHandlerConcurrent(t *testing.T) {
+	primary := newFailoverTestHandler(func(context.Context) error {
+		time.Sleep(2 * time.Millisecond)
+		return nil
+	})
+	secondary := newFailoverTestHandler(nil)
+	handler := NewFailoverHandler(NewFailoverOptions().WithTimeout(time.Second), primary, secondary)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := handler.Handle(context.Background(), slog.NewRecord(time.Now(), LevelInfo, "record", 0)); err != nil {
+				t.Errorf("Handle() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if handler.Active() != 0 || len(primary.Messages()) != 20 || len(secondary.Messages()) != 0 {
+		t.Fatalf("active = %d, primary = %d, secondary = %d records, want all 20 on the primary",
+			handler.Active(), len(primary.Messages()), len(secondary.Messages()))
+	}
+}
@@ -0,0 +1,208 @@
+package log
+
+import (
+	"context"
+	"github.com/kercylan98/go-log/log/internal/expr"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	_ FilterHandler = (*filterHandler)(nil)
+
+	filterExprConfig = expr.Config{
+		Constants: map[string]expr.Value{
+			"DEBUG": expr.Number(float64(LevelDebug)),
+			"INFO":  expr.Number(float64(LevelInfo)),
+			"WARN":  expr.Number(float64(LevelWarn)),
+			"ERROR": expr.Number(float64(LevelError)),
+		},
+		Roots: []string{"level", "msg", "group", "attrs."},
+	}
+)
+
+// FilterSyntaxError 是过滤表达式编译失败时返回的错误，它包含表达式、错误在表达式中的字节偏移及错误信息
+type FilterSyntaxError = expr.SyntaxError
+
+// FilterOptions 是 FilterHandler 的选项
+type FilterOptions struct {
+	drop bool // 是否丢弃匹配的日志记录
+}
+
+// NewFilterOptions 创建一个默认的 FilterOptions
+//   - 默认仅保留匹配表达式的日志记录
+func NewFilterOptions() *FilterOptions {
+	return &FilterOptions{}
+}
+
+// WithDrop 设置是否丢弃匹配表达式的日志记录，否则仅保留匹配表达式的日志记录
+func (o *FilterOptions) WithDrop(drop bool) *FilterOptions {
+	o.drop = drop
+	return o
+}
+
+// FilterHandler 是一个根据表达式保留或丢弃日志记录的日志处理器
+//
+// 表达式可以使用以下标识符：
+//   - level：日志级别，可以与 DEBUG、INFO、WARN 及 ERROR 常量进行比较
+//   - msg：日志消息
+//   - group：通过 WithGroup 构建的分组路径，例如 "payment.api"
+//   - attrs.<key>：日志记录或 WithAttrs 中指定键的属性值，分组内的属性以 "." 连接，例如 "attrs.http.status"，时间间隔以秒为单位的数值表示
+//
+// 例如 `level >= WARN || (group ~ "payment.*" && attrs.amount > 1000)`，完整的语法可参阅 NewFilterHandler
+type FilterHandler interface {
+	Handler
+
+	// Expression 获取当前的表达式
+	Expression() string
+
+	// SetExpression 编译并替换当前的表达式，编译失败时将保持原有的表达式
+	SetExpression(expression string) error
+
+	// Dropped 获取被丢弃的日志记录数量
+	Dropped() uint64
+}
+
+// NewFilterHandler 创建一个包装 handler 的过滤日志处理器，当表达式编译失败时将返回 *FilterSyntaxError 错误，当 options 为 nil 时将使用默认选项
+//
+// 表达式的语法如下：
+//
+//	expression = or
+//	or         = and { "||" and }
+//	and        = not { "&&" not }
+//	not        = "!" not | compare
+//	compare    = operand [ ( "==" | "!=" | "<" | "<=" | ">" | ">=" ) operand ]
+//	           | operand ( "~" | "!~" | "=~" ) string
+//	operand    = "(" expression ")" | string | number | "true" | "false" | "null" | identifier
+//	identifier = name { "." name }
+//
+// 其中：
+//   - string 是双引号包裹的 Go 字符串字面量，例如 "payment.*"
+//   - number 是十进制数值，例如 1000、-1.5 或 1e3
+//   - identifier 是 FilterHandler 中所描述的标识符或级别常量，不存在的属性值为 null
+//   - identifier 以字母或 "_" 开头，由字母、数字、"_"、"-" 及 "." 组成，例如 attrs.x-request-id
+//   - "~" 及 "!~" 使用 path.Match 的通配符语法进行匹配，其中 * 可以匹配包含 "." 的任意字符序列
+//   - "=~" 使用正则表达式进行匹配，正则表达式在编译时被解析
+//   - 比较运算仅在类型相同时成立，例如数值与字符串的比较总是为 false，"!=" 则为 true
+//   - 在布尔上下文中，false、0、空字符串及 null 为假，其余为真
+func NewFilterHandler(handler Handler, expression string, options *FilterOptions) (FilterHandler, error) {
+	if options == nil {
+		options = NewFilterOptions()
+	}
+	s := &filterState{drop: options.drop}
+	if err := s.compile(expression); err != nil {
+		return nil, err
+	}
+	return &filterHandler{state: s, handler: handler}, nil
+}
+
+type filterHandler struct {
+	state   *filterState // 过滤状态，它在所有派生的处理器间共享
+	handler slog.Handler // 被包装的处理器
+	attrs   []slog.Attr  // 通过 WithAttrs 累积的属性
+	group   string       // 通过 WithGroup 构建的分组路径
+}
+
+func (h *filterHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *filterHandler) Handle(ctx context.Context, record slog.Record) error {
+	ctx = withCallerFrames(ctx)
+	ctx, record = resolveContextAttrs(ctx, record)
+	matched := h.state.program.Load().Eval(&filterEnv{handler: h, record: record})
+	if matched == h.state.drop {
+		h.state.dropped.Add(1)
+		return nil
+	}
+	return h.handler.Handle(ctx, record)
+}
+
+func (h *filterHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &filterHandler{
+		state:   h.state,
+		handler: h.handler.WithAttrs(attrs),
+		attrs:   append(h.attrs[:len(h.attrs):len(h.attrs)], attrs...),
+		group:   h.group,
+	}
+}
+
+func (h *filterHandler) WithGroup(name string) slog.Handler {
+	n := &filterHandler{state: h.state, handler: h.handler.WithGroup(name), attrs: h.attrs, group: name}
+	if h.group != "" {
+		n.group = h.group + "." + name
+	}
+	return n
+}
+
+func (h *filterHandler) Expression() string {
+	return h.state.program.Load().String()
+}
+
+func (h *filterHandler) SetExpression(expression string) error {
+	return h.state.compile(expression)
+}
+
+func (h *filterHandler) Dropped() uint64 {
+	return h.state.dropped.Load()
+}
+
+type filterState struct {
+	program atomic.Pointer[expr.Program] // 编译后的表达式
+	drop    bool                         // 是否丢弃匹配的日志记录
+	dropped atomic.Uint64                // 被丢弃的日志记录数量
+}
+
+func (s *filterState) compile(expression string) error {
+	program, err := expr.Compile(expression, filterExprConfig)
+	if err != nil {
+		return err
+	}
+	s.program.Store(program)
+	return nil
+}
+
+// filterEnv 是日志记录的表达式求值环境
+type filterEnv struct {
+	handler *filterHandler
+	record  slog.Record
+}
+
+func (e *filterEnv) Lookup(path []string) (expr.Value, bool) {
+	switch path[0] {
+	case "level":
+		return expr.Number(float64(e.record.Level)), true
+	case "msg":
+		return expr.String(e.record.Message), true
+	case "group":
+		return expr.String(e.handler.group), true
+	}
+	value, found := findRecordAttr(e.handler.attrs, e.record, path[1:])
+	if !found {
+		return expr.Null(), false
+	}
+	return filterExprValue(value), true
+}
+
+func filterExprValue(value slog.Value) expr.Value {
+	switch value.Kind() {
+	case slog.KindBool:
+		return expr.Bool(value.Bool())
+	case slog.KindInt64:
+		return expr.Number(float64(value.Int64()))
+	case slog.KindUint64:
+		return expr.Number(float64(value.Uint64()))
+	case slog.KindFloat64:
+		return expr.Number(value.Float64())
+	case slog.KindDuration:
+		return expr.Number(value.Duration().Seconds())
+	case slog.KindTime:
+		return expr.String(value.Time().Format(time.RFC3339Nano))
+	case slog.KindAny:
+		if err, ok := value.Any().(error); ok {
+			return expr.String(err.Error())
+		}
+	}
+	return expr.String(value.String())
+}
@@ -0,0 +1,46 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestFilterHandler tests that records are kept by expression against level, group and attrs, including keys containing '-'.
+func TestFilterHandler(t *testing.T) {
+	var buf bytes.Buffer
+	filter, err := NewFilterHandler(newHandler(GetConfigBuilder().Test().WithWriter(&buf)),
+		`level >= WARN || (group ~ "payment.*" && attrs.amount > 1000)`, nil)
+	if err != nil {
+		t.Fatalf("NewFilterHandler() error = %v", err)
+	}
+	logger := GetBuilder().FromHandler(filter)
+	payment := logger.WithGroup("payment").WithGroup("api")
+
+	logger.Info("dropped", "amount", 5000)
+	payment.Info("small", "amount", 10)
+	payment.Info("large", "amount", 5000)
+	logger.Warn("warned")
+
+	if out := buf.String(); strings.Count(out, "\n") != 2 || !strings.Contains(out, "Large") || !strings.Contains(out, "Warned") {
+		t.Fatalf("unexpected output: %s", out)
+	}
+	if filter.Dropped() != 2 {
+		t.Fatalf("Dropped() = %d, want 2", filter.Dropped())
+	}
+	buf.Reset()
+	if err = filter.SetExpression(`attrs.x-request-id == "abc"`); err != nil {
+		t.Fatalf("SetExpression() error = %v", err)
+	}
+	logger.Info("kept", "x-request-id", "abc")
+	logger.Info("skipped", "x-request-id", "def")
+	if out := buf.String(); strings.Count(out, "\n") != 1 || !strings.Contains(out, "Kept") {
+		t.Fatalf("attr key containing '-' is not matched: %s", out)
+	}
+
+	var syntaxErr *FilterSyntaxError
+	if err = filter.SetExpression(`level >=`); !errors.As(err, &syntaxErr) || syntaxErr.Pos != len(`level >=`) || filter.Expression() == `level >=` {
+		t.Fatalf("invalid expression is accepted or not reported as *FilterSyntaxError: %v", err)
+	}
+}
@@ -0,0 +1,259 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+var _ FingersCrossedHandler = (*fingersCrossedHandler)(nil)
+
+// FingersCrossedOptions 是 FingersCrossedHandler 的选项
+type FingersCrossedOptions struct {
+	triggerLevel Leveler // 触发级别
+	bufferLevel  Leveler // 缓冲的最低级别
+	maxRecords   int     // 每个作用域最多缓冲的日志记录数量
+}
+
+// NewFingersCrossedOptions 创建一个默认的 FingersCrossedOptions
+//   - 默认在 LevelError 时触发，缓冲 LevelDebug 及以上级别的日志记录，每个作用域最多缓冲 1000 条日志记录
+func NewFingersCrossedOptions() *FingersCrossedOptions {
+	return &FingersCrossedOptions{
+		triggerLevel: LevelError,
+		bufferLevel:  LevelDebug,
+		maxRecords:   1000,
+	}
+}
+
+// WithTriggerLevel 设置触发级别，当作用域内出现该级别及以上的日志记录时，将输出该作用域内缓冲的所有日志记录
+func (o *FingersCrossedOptions) WithTriggerLevel(leveler Leveler) *FingersCrossedOptions {
+	o.triggerLevel = leveler
+	return o
+}
+
+// WithBufferLevel 设置缓冲的最低级别，低于该级别的日志记录将被直接丢弃
+func (o *FingersCrossedOptions) WithBufferLevel(leveler Leveler) *FingersCrossedOptions {
+	o.bufferLevel = leveler
+	return o
+}
+
+// WithMaxRecords 设置每个作用域最多缓冲的日志记录数量，超出时将丢弃最早的日志记录
+func (o *FingersCrossedOptions) WithMaxRecords(max int) *FingersCrossedOptions {
+	o.maxRecords = max
+	return o
+}
+
+// FingersCrossedHandler 是一个在错误发生时才输出调试上下文的日志处理器
+//   - 在通过 WithFingersCrossedScope 创建的作用域内，被包装的处理器未启用的日志记录将被缓冲
+//   - 当作用域内出现触发级别及以上的日志记录时，缓冲的日志记录将以原始的调用者、属性及分组先行输出，此后该作用域内的日志记录将直接输出
+//   - 当作用域结束且未被触发时，缓冲的日志记录将被丢弃
+//   - 缓冲的日志记录在持有作用域的锁时输出，以保证它们先于作用域内此后的日志记录，因此被包装的处理器不应在处理时使用同一作用域记录日志
+type FingersCrossedHandler interface {
+	Handler
+
+	// Trigger 主动触发 ctx 所在的作用域，输出缓冲的日志记录
+	Trigger(ctx context.Context) error
+}
+
+// NewFingersCrossedHandler 创建一个包装 handler 的 FingersCrossedHandler，当 options 为 nil 时将使用默认选项
+func NewFingersCrossedHandler(handler Handler, options *FingersCrossedOptions) FingersCrossedHandler {
+	if options == nil {
+		options = NewFingersCrossedOptions()
+	}
+	return &fingersCrossedHandler{
+		state: &fingersCrossedState{
+			handler:      handler,
+			triggerLevel: options.triggerLevel,
+			bufferLevel:  options.bufferLevel,
+			maxRecords:   max(options.maxRecords, 1),
+		},
+		handler: handler,
+	}
+}
+
+type fingersCrossedScopeKey struct{}
+
+// WithFingersCrossedScope 创建一个 FingersCrossedHandler 的作用域，例如一次请求，返回的函数用于结束该作用域并丢弃未被触发的缓冲
+func WithFingersCrossedScope(ctx context.Context) (context.Context, func()) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	scope := &fingersCrossedScope{buffers: make(map[*fingersCrossedState]*fingersCrossedBuffer)}
+	return context.WithValue(ctx, fingersCrossedScopeKey{}, scope), scope.end
+}
+
+func fingersCrossedScopeFrom(ctx context.Context) *fingersCrossedScope {
+	if ctx == nil {
+		return nil
+	}
+	scope, _ := ctx.Value(fingersCrossedScopeKey{}).(*fingersCrossedScope)
+	return scope
+}
+
+type fingersCrossedHandler struct {
+	state   *fingersCrossedState // 共享状态，它在所有派生的处理器间共享
+	handler slog.Handler         // 被包装的处理器
+}
+
+func (h *fingersCrossedHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if h.handler.Enabled(ctx, level) {
+		return true
+	}
+	return fingersCrossedScopeFrom(ctx) != nil && level >= h.state.bufferLevel.Level()
+}
+
+func (h *fingersCrossedHandler) Handle(ctx context.Context, record slog.Record) error {
+	ctx = withCallerFrames(ctx)
+	ctx, record = resolveContextAttrs(ctx, record)
+	enabled := h.handler.Enabled(ctx, record.Level)
+	scope := fingersCrossedScopeFrom(ctx)
+	if scope == nil {
+		if !enabled {
+			return nil
+		}
+		return h.handler.Handle(ctx, record)
+	}
+
+	pass, err := scope.handle(h, ctx, record, enabled)
+	if pass {
+		err = errors.Join(err, h.output(ctx, record, enabled))
+	}
+	return err
+}
+
+// output 直接输出日志记录
+func (h *fingersCrossedHandler) output(ctx context.Context, record slog.Record, enabled bool) error {
+	if !enabled {
+		// 触发后的日志记录需要越过被包装的处理器的日志级别
+		ctx = WithForcedLevel(ctx, record.Level)
+	}
+	return h.handler.Handle(ctx, record)
+}
+
+func (h *fingersCrossedHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &fingersCrossedHandler{state: h.state, handler: h.handler.WithAttrs(attrs)}
+}
+
+func (h *fingersCrossedHandler) WithGroup(name string) slog.Handler {
+	return &fingersCrossedHandler{state: h.state, handler: h.handler.WithGroup(name)}
+}
+
+func (h *fingersCrossedHandler) Trigger(ctx context.Context) error {
+	scope := fingersCrossedScopeFrom(ctx)
+	if scope == nil {
+		return nil
+	}
+	return scope.trigger(ctx, h.state)
+}
+
+type fingersCrossedState struct {
+	handler      slog.Handler // 用于记录丢弃摘要的处理器
+	triggerLevel Leveler
+	bufferLevel  Leveler
+	maxRecords   int
+}
+
+// flush 输出缓冲的日志记录，当存在因超出上限而被丢弃的日志记录时，将先记录一条摘要
+func (s *fingersCrossedState) flush(ctx context.Context, buffer *fingersCrossedBuffer) error {
+	if buffer == nil {
+		return nil
+	}
+	var err error
+	if buffer.dropped > 0 {
+		summary := slog.NewRecord(time.Now(), LevelWarn, "log fingers crossed buffer dropped records", 0)
+		summary.AddAttrs(slog.Int("dropped", buffer.dropped), slog.Int("max_records", s.maxRecords))
+		err = s.handler.Handle(WithForcedLevel(withoutCallerFrames(ctx), summary.Level), summary)
+	}
+	for _, entry := range buffer.entries {
+		// 被包装的处理器未启用缓冲的日志记录，因此需要通过强制级别输出
+		err = errors.Join(err, entry.handler.Handle(WithForcedLevel(entry.ctx, entry.record.Level), entry.record))
+	}
+	return err
+}
+
+type fingersCrossedEntry struct {
+	ctx     context.Context // 包含调用栈的上下文
+	handler slog.Handler    // 缓冲时的处理器，它包含 WithAttrs 及 WithGroup 的结果
+	record  slog.Record
+}
+
+type fingersCrossedBuffer struct {
+	entries   []fingersCrossedEntry
+	dropped   int  // 因超出上限而被丢弃的日志记录数量
+	triggered bool // 是否已被触发
+}
+
+type fingersCrossedScope struct {
+	rw      sync.Mutex
+	buffers map[*fingersCrossedState]*fingersCrossedBuffer
+	ended   bool
+}
+
+// handle 缓冲日志记录或在触发时输出缓冲及触发的日志记录，pass 表示日志记录是否应当被直接输出
+func (s *fingersCrossedScope) handle(h *fingersCrossedHandler, ctx context.Context, record slog.Record, enabled bool) (pass bool, err error) {
+	s.rw.Lock()
+	defer s.rw.Unlock()
+	if s.ended {
+		return enabled, nil
+	}
+
+	buffer, exist := s.buffers[h.state]
+	if !exist {
+		buffer = new(fingersCrossedBuffer)
+		s.buffers[h.state] = buffer
+	}
+	switch {
+	case buffer.triggered:
+		return true, nil
+	case record.Level >= h.state.triggerLevel.Level():
+		// 在持有锁时输出，使得其他协程只能在缓冲及触发的日志记录输出后观察到已触发的状态
+		err = h.state.flush(ctx, s.take(h.state, buffer))
+		return false, errors.Join(err, h.output(ctx, record, enabled))
+	case enabled:
+		return true, nil
+	case record.Level < h.state.bufferLevel.Level():
+		return false, nil
+	}
+
+	buffer.entries = append(buffer.entries, fingersCrossedEntry{ctx: ctx, handler: h.handler, record: record.Clone()})
+	if len(buffer.entries) > h.state.maxRecords {
+		buffer.entries[0] = fingersCrossedEntry{}
+		buffer.entries = buffer.entries[1:]
+		buffer.dropped++
+	}
+	return false, nil
+}
+
+// trigger 将作用域标记为已触发并在持有锁时输出缓冲的日志记录
+func (s *fingersCrossedScope) trigger(ctx context.Context, state *fingersCrossedState) error {
+	s.rw.Lock()
+	defer s.rw.Unlock()
+	if s.ended {
+		return nil
+	}
+	buffer, exist := s.buffers[state]
+	if !exist {
+		buffer = new(fingersCrossedBuffer)
+		s.buffers[state] = buffer
+	}
+	if buffer.triggered {
+		return nil
+	}
+	return state.flush(ctx, s.take(state, buffer))
+}
+
+// take 将缓冲标记为已触发并取出其中的日志记录
+func (s *fingersCrossedScope) take(state *fingersCrossedState, buffer *fingersCrossedBuffer) *fingersCrossedBuffer {
+	taken := &fingersCrossedBuffer{entries: buffer.entries, dropped: buffer.dropped}
+	s.buffers[state] = &fingersCrossedBuffer{triggered: true}
+	return taken
+}
+
+func (s *fingersCrossedScope) end() {
+	s.rw.Lock()
+	defer s.rw.Unlock()
+	s.ended = true
+	s.buffers = nil
+}
@@ -0,0 +1,146 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestFingersCrossedHandler tests that buffered debug records are flushed with their caller when an error occurs
+// and discarded when the scope ends without one.
+func TestFingersCrossedHandler(t *testing.T) {
+	var buf bytes.Buffer
+	handler := NewFingersCrossedHandler(newHandler(GetConfigBuilder().Production().WithWriter(&buf)), nil)
+	logger := GetBuilder().FromHandler(handler)
+
+	ctx, end := WithFingersCrossedScope(context.Background())
+	logger.DebugContext(ctx, "discarded")
+	logger.InfoContext(ctx, "passed")
+	end()
+	if strings.Contains(buf.String(), "Discarded") || !strings.Contains(buf.String(), "Passed") {
+		t.Fatalf("unexpected output: %s", buf.String())
+	}
+
+	buf.Reset()
+	ctx, end = WithFingersCrossedScope(context.Background())
+	defer end()
+	logger.With("request", 1).WithGroup("db").DebugContext(ctx, "query", "sql", "select 1")
+	logger.ErrorContext(ctx, "failed")
+	logger.DebugContext(ctx, "after")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("output lines = %d, want 3: %s", len(lines), buf.String())
+	}
+	if !strings.Contains(lines[0], "Query") || !strings.Contains(lines[0], "request") || !strings.Contains(lines[0], "fingers_crossed_handler_test.go") {
+		t.Fatalf("buffered record lost fidelity: %s", lines[0])
+	}
+	if !strings.Contains(lines[1], "Failed") || !strings.Contains(lines[2], "After") {
+		t.Fatalf("unexpected order: %s", buf.String())
+	}
+}
+
+// signalWriter signals and stalls on its first write, so that other goroutines run while it is in progress.
+type signalWriter struct {
+	syncBuffer
+	once    sync.Once
+	started chan struct{}
+}
+
+func (w *signalWriter) Write(p []byte) (int, error) {
+	w.once.Do(func() {
+		close(w.started)
+		time.Sleep(50 * time.Millisecond)
+	})
+	return w.syncBuffer.Write(p)
+}
+
+// TestFingersCrossedHandlerOrder tests that a record from another goroutine logged while the buffer is flushed
+// is written after the buffered and the triggering records.
+func TestFingersCrossedHandlerOrder(t *testing.T) {
+	writer := &signalWriter{started: make(chan struct{})}
+	handler := NewFingersCrossedHandler(newHandler(GetConfigBuilder().Production().WithWriter(writer)), nil)
+	logger := GetBuilder().FromHandler(handler)
+
+	ctx, end := WithFingersCrossedScope(context.Background())
+	defer end()
+	for i := 0; i < 3; i++ {
+		logger.DebugContext(ctx, "buffered", "i", i)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		<-writer.started
+		logger.DebugContext(ctx, "after")
+	}()
+	logger.ErrorContext(ctx, "failed")
+	<-done
+
+	lines := strings.Split(strings.TrimSpace(writer.String()), "\n")
+	want := []string{"Buffered", "Buffered", "Buffered", "Failed", "After"}
+	if len(lines) != len(want) {
+		t.Fatalf("output lines = %d, want %d: %s", len(lines), len(want), writer.String())
+	}
+	for i := range want {
+		if !strings.Contains(lines[i], want[i]) {
+			t.Fatalf("unexpected order: %s", writer.String())
+		}
+	}
+}
+
+// TestFingersCrossedHandlerTrigger tests that Trigger flushes the buffer once and that later records are written directly.
+func TestFingersCrossedHandlerTrigger(t *testing.T) {
+	var buf bytes.Buffer
+	handler := NewFingersCrossedHandler(newHandler(GetConfigBuilder().Production().WithWriter(&buf)), nil)
+	logger := GetBuilder().FromHandler(handler)
+
+	ctx, end := WithFingersCrossedScope(context.Background())
+	defer end()
+	logger.DebugContext(ctx, "buffered")
+	if buf.Len() != 0 {
+		t.Fatalf("record is written before the trigger: %s", buf.String())
+	}
+	if err := handler.Trigger(ctx); err != nil {
+		t.Fatalf("Trigger() error = %v", err)
+	}
+	_ = handler.Trigger(ctx)
+	logger.DebugContext(ctx, "direct")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], "Buffered") || !strings.Contains(lines[1], "Direct") {
+		t.Fatalf("unexpected output: %s", buf.String())
+	}
+	if err := handler.Trigger(context.Background()); err != nil {
+		t.Fatalf("Trigger() without a scope error = %v", err)
+	}
+}
+
+// TestFingersCrossedHandlerOverflow tests that the oldest records are dropped beyond the limit and that a summary precedes the flush.
+func TestFingersCrossedHandlerOverflow(t *testing.T) {
+	var buf bytes.Buffer
+	handler := NewFingersCrossedHandler(newHandler(GetConfigBuilder().Production().WithWriter(&buf)), NewFingersCrossedOptions().WithMaxRecords(2))
+	logger := GetBuilder().FromHandler(handler)
+
+	ctx, end := WithFingersCrossedScope(context.Background())
+	defer end()
+	for i := 0; i < 5; i++ {
+		logger.DebugContext(ctx, "buffered", "i", i)
+	}
+	logger.ErrorContext(ctx, "failed")
+
+	plain := regexp.MustCompile("\x1b+\\[[0-9;]*m").ReplaceAllString(buf.String(), "")
+	lines := strings.Split(strings.TrimSpace(plain), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("output lines = %d, want 4: %s", len(lines), plain)
+	}
+	for i, want := range []string{"dropped=3", "i=3", "i=4", "Failed"} {
+		if !strings.Contains(lines[i], want) {
+			t.Fatalf("line %d %q misses %q", i, lines[i], want)
+		}
+	}
+}
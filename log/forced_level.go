@@ -0,0 +1,33 @@
+package log
+
+import (
+	"context"
+)
+
+type forcedLevelKey struct{}
+
+// WithForcedLevel 返回一个强制启用 level 及以上级别日志的上下文，它不会改变全局的日志级别，通常被用于排查特定请求
+//   - 仅通过 *Context 方法记录的日志能够获取到上下文，例如 DebugContext
+//   - 强制级别仅会降低日志级别，当配置的日志级别更低时，将以配置的日志级别为准
+//   - 它将通过 Multi 及各类包装的处理器传递到最终的处理器，WebhookHandler 作为告警通道不受强制级别的影响
+func WithForcedLevel(ctx context.Context, level Level) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, forcedLevelKey{}, level)
+}
+
+// ForcedLevel 获取通过 WithForcedLevel 存入上下文中的强制级别
+func ForcedLevel(ctx context.Context) (level Level, ok bool) {
+	if ctx == nil {
+		return level, false
+	}
+	level, ok = ctx.Value(forcedLevelKey{}).(Level)
+	return level, ok
+}
+
+// isForced 检查 level 是否被上下文中的强制级别启用
+func isForced(ctx context.Context, level Level) bool {
+	forced, ok := ForcedLevel(ctx)
+	return ok && level >= forced
+}
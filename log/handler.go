@@ -3,6 +3,7 @@ package log
 import (
 	"context"
 	"encoding"
+	"errors"
 	"fmt"
 	"github.com/fatih/color"
 	jsonIter "github.com/json-iterator/go"
@@ -40,46 +41,107 @@ type handler struct {
 }
 
 func (h *handler) Enabled(ctx context.Context, level slog.Level) bool {
-	return level >= h.options.FetchLeveler().Level()
+	return h.enabled(ctx, level, h.options)
+}
+
+// enabled 检查日志级别是否启用，上下文中的强制级别优先于分组及全局的日志级别
+func (h *handler) enabled(ctx context.Context, level slog.Level, options LoggerOptionsFetcher) bool {
+	if isForced(ctx, level) {
+		return true
+	}
+	if h.group != "" {
+		if leveler := options.FetchGroupLevel(h.group); leveler != nil {
+			return level >= leveler.Level()
+		}
+	}
+	return level >= options.FetchLeveler().Level()
 }
 
 func (h *handler) Handle(ctx context.Context, record slog.Record) error {
 	// Create a local copy of options to avoid race conditions
 	options := h.options.FetchCopy()
-	if !h.Enabled(ctx, options.FetchLeveler().Level()) {
+	if !h.enabled(ctx, record.Level, options) {
 		return nil
 	}
 
+	if options.FetchCaller() || options.FetchErrTrackLevel(record.Level) {
+		ctx = withCallerFrames(ctx)
+	}
+
+	record = withContextAttrs(ctx, record, options.FetchContextExtractors())
+
+	var hookErr error
+	if hooks := options.FetchPreHandleHooks(record.Level); len(hooks) > 0 {
+		// 钩子可能会修改日志记录，因此需要避免影响调用者所持有的日志记录
+		record = record.Clone()
+		var pass bool
+		if pass, hookErr = runPreHandleHooks(ctx, hooks, h.group, &record); !pass {
+			return errors.Join(hookErr, runPostHandleHooks(ctx, options.FetchPostHandleHooks(record.Level), h.group, record, ErrRecordVetoed))
+		}
+	}
+
+	recordBytes, err := h.format(ctx, record, options)
+	if err == nil {
+		_, err = options.FetchWriter().Write(recordBytes)
+	}
+	if hooks := options.FetchPostHandleHooks(record.Level); len(hooks) > 0 {
+		hookErr = errors.Join(hookErr, runPostHandleHooks(ctx, hooks, h.group, record, err))
+	}
+	return errors.Join(err, hookErr)
+}
+
+// format 将日志记录格式化为文本，它不会进行级别检查，也不会写入到日志写入器
+func (h *handler) format(ctx context.Context, record slog.Record, options LoggerOptionsFetcher) ([]byte, error) {
 	var builder = colorbuilder.NewBuilder()
 	defer builder.Reset()
 
+	limits := options.FetchRecordLimits()
+	record.Message = limits.truncateString(record.Message)
+
 	h.formatTime(ctx, record, builder, options)
 	h.formatLevel(ctx, record, builder, options)
 	h.formatCaller(ctx, record, builder, options)
 	h.formatGroup(ctx, record, builder, options)
 	h.formatMessage(ctx, record, builder, options)
 
-	// fixed attrs
-	num := record.NumAttrs()
-	fixedNum := len(h.attrs)
-	for i, attr := range h.attrs {
-		h.formatAttr(ctx, record.Level, attr, builder, num+fixedNum == i+1, options)
+	// fixed attrs, the enrichment is fetched on every record so that configuration changes apply to built loggers
+	fixed := h.attrs
+	if enrichment := options.FetchEnrichment(); len(enrichment) > 0 {
+		fixed = append(enrichment[:len(enrichment):len(enrichment)], h.attrs...)
 	}
+	num := record.NumAttrs()
+	fixedNum := len(fixed)
+	if limits.exceedsAttrs(num + fixedNum) {
+		attrs := limits.limitAttrs(fixed, record)
+		for i, attr := range attrs {
+			if len(attrs) != i+1 {
+				// 最后一个属性是截断标记，它不应当再被截断
+				attr = limits.limitAttr(attr)
+			}
+			h.formatAttr(ctx, record.Level, attr, builder, len(attrs) == i+1, options)
+		}
+	} else {
+		for i, attr := range fixed {
+			h.formatAttr(ctx, record.Level, limits.limitAttr(attr), builder, num+fixedNum == i+1, options)
+		}
 
-	idx := 0
-	record.Attrs(func(attr slog.Attr) bool {
-		idx++
-		h.formatAttr(ctx, record.Level, attr, builder, num == idx, options)
-		return true
-	})
+		idx := 0
+		record.Attrs(func(attr slog.Attr) bool {
+			idx++
+			h.formatAttr(ctx, record.Level, limits.limitAttr(attr), builder, num == idx, options)
+			return true
+		})
+	}
 
 	recordBytes, err := builder.Write('\n').Bytes()
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	_, err = options.FetchWriter().Write(recordBytes)
-	return err
+	var reset string
+	if options.FetchEnableColor() {
+		reset = "\x1b[0m"
+	}
+	return limits.truncateRecord(recordBytes, reset), nil
 }
 
 func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
@@ -139,9 +201,11 @@ func (h *handler) formatCaller(ctx context.Context, record slog.Record, builder
 	if !options.FetchCaller() {
 		return
 	}
-	pcs := make([]uintptr, 1)
-	runtime.CallersFrames(pcs[:runtime.Callers(options.FetchCallerSkip(), pcs)])
-	fs := runtime.CallersFrames(pcs)
+	pcs := callerFrames(ctx, options.FetchCallerSkip())
+	if len(pcs) == 0 {
+		return
+	}
+	fs := runtime.CallersFrames(pcs[:1])
 	f, _ := fs.Next()
 	if f.File == "" {
 		return
@@ -210,9 +274,7 @@ func (h *handler) formatAttr(ctx context.Context, level slog.Level, attr slog.At
 			h.loadColorWithOptions(builder, ColorTypeAttrErrorKey, options)
 		case error:
 			if options.FetchErrTrackLevel(level) && !options.FetchTrackBeautify() {
-				pc := make([]uintptr, 10)
-				n := runtime.Callers(options.FetchCallerSkip()+3, pc)
-				frames := runtime.CallersFrames(pc[:n])
+				frames := runtime.CallersFrames(h.errTrackFrames(ctx, options))
 				var stacks = make(stackErrorTracks, 0, 10)
 				for {
 					frame, more := frames.Next()
@@ -268,9 +330,7 @@ func (h *handler) formatAttrValue(ctx context.Context, level slog.Level, fullKey
 			builder.WriteString(strconv.Quote(v.Error()))
 
 			if options.FetchErrTrackLevel(level) && options.FetchTrackBeautify() {
-				pc := make([]uintptr, 10)
-				n := runtime.Callers(options.FetchCallerSkip()+3, pc)
-				frames := runtime.CallersFrames(pc[:n])
+				frames := runtime.CallersFrames(h.errTrackFrames(ctx, options))
 				if options.FetchTrackBeautify() {
 					h.loadColorWithOptions(builder, ColorTypeErrorTrackHeader, options).
 						WriteSprintfToEnd("\tError Track: [%s] >> %s", fullKey, v.Error())
@@ -322,7 +382,7 @@ func (h *handler) formatAttrValue(ctx context.Context, level slog.Level, fullKey
 			if err != nil {
 				jsonBytes = []byte("{}")
 			}
-			builder.WriteString(string(jsonBytes))
+			builder.WriteString(string(options.FetchRecordLimits().truncateAny(jsonBytes)))
 		}
 	}
 
@@ -331,6 +391,15 @@ func (h *handler) formatAttrValue(ctx context.Context, level slog.Level, fullKey
 	}
 }
 
+// errTrackFrames 获取错误追踪的调用栈，它从调用者开始，最多包含 10 层
+func (h *handler) errTrackFrames(ctx context.Context, options LoggerOptionsFetcher) []uintptr {
+	pcs := callerFrames(ctx, options.FetchCallerSkip())
+	if len(pcs) > 10 {
+		pcs = pcs[:10]
+	}
+	return pcs
+}
+
 func (h *handler) loadColor(builder *colorbuilder.Builder, t ColorType) *colorbuilder.Builder {
 	// For backward compatibility, use h.options if no specific options are provided
 	var c *color.Color
@@ -1,8 +1,12 @@
 package log
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -67,3 +71,140 @@ func TestHandlerRaceCondition(t *testing.T) {
 	// Wait for all goroutines to finish
 	wg.Wait()
 }
+
+// TestHandlerGroupLevel tests that group level overrides are resolved against the group path and can be changed at runtime.
+func TestHandlerGroupLevel(t *testing.T) {
+	config := GetConfigBuilder().Production().
+		WithGroupLevel("database.*", LevelDebug).
+		WithGroupLevel("database.orders", LevelError)
+	root := newHandler(config)
+	database := root.WithGroup("database")
+	ctx := context.Background()
+
+	if root.Enabled(ctx, LevelDebug) {
+		t.Fatal("root handler is enabled at debug level")
+	}
+	if !database.WithGroup("users").Enabled(ctx, LevelDebug) || !database.WithGroup("users").WithGroup("read").Enabled(ctx, LevelDebug) {
+		t.Fatal("database.* does not enable debug level")
+	}
+	if database.WithGroup("orders").Enabled(ctx, LevelWarn) {
+		t.Fatal("exact pattern does not take precedence over glob")
+	}
+
+	config.WithGroupLevel("database.*", nil)
+	if database.WithGroup("users").Enabled(ctx, LevelDebug) {
+		t.Fatal("group level is not removed at runtime")
+	}
+
+	for i := 0; i < groupLevelCacheSize*2; i++ {
+		config.FetchGroupLevel(fmt.Sprintf("database.dynamic_%d", i))
+	}
+	if size := config.(*loggerConfiguration).groupLevelCache.size.Load(); size > groupLevelCacheSize {
+		t.Fatalf("group level cache size = %d, want <= %d", size, groupLevelCacheSize)
+	}
+	if config.FetchGroupLevel("database.orders") == nil {
+		t.Fatal("uncached group path is not matched")
+	}
+}
+
+// TestHandlerHooks tests that pre-handle hooks can modify and veto records, post-handle hooks receive the outcome
+// and a panicking hook does not break logging.
+func TestHandlerHooks(t *testing.T) {
+	var (
+		buf      bytes.Buffer
+		outcomes []error
+	)
+	config := GetConfigBuilder().Test().WithWriter(&buf).
+		WithPreHandleHook(func(ctx context.Context, group string, record *slog.Record) bool {
+			record.AddAttrs(slog.String("hooked", group))
+			return record.Message != "vetoed"
+		}).
+		WithPreHandleHook(func(ctx context.Context, group string, record *slog.Record) bool {
+			panic("boom")
+		}, LevelError).
+		WithPostHandleHook(func(ctx context.Context, group string, record slog.Record, err error) {
+			outcomes = append(outcomes, err)
+		})
+	handler := newHandler(config).WithGroup("payment")
+	ctx := context.Background()
+
+	if err := handler.Handle(ctx, slog.NewRecord(time.Now(), LevelInfo, "kept", 0)); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	if err := handler.Handle(ctx, slog.NewRecord(time.Now(), LevelInfo, "vetoed", 0)); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	if err := handler.Handle(ctx, slog.NewRecord(time.Now(), LevelError, "panicked", 0)); err == nil {
+		t.Fatal("Handle() does not report the hook panic")
+	}
+
+	out := buf.String()
+	if strings.Count(out, `"payment"`) != 2 || strings.Contains(out, "Vetoed") || !strings.Contains(out, "Panicked") {
+		t.Fatalf("unexpected output: %s", out)
+	}
+	if len(outcomes) != 3 || outcomes[0] != nil || !errors.Is(outcomes[1], ErrRecordVetoed) || outcomes[2] != nil {
+		t.Fatalf("unexpected outcomes: %v", outcomes)
+	}
+}
+
+// TestHandlerContextAttrs tests that attrs stashed in the context and extracted by registered extractors are logged.
+func TestHandlerContextAttrs(t *testing.T) {
+	type tenantKey struct{}
+	var buf bytes.Buffer
+	config := GetConfigBuilder().Test().WithWriter(&buf).
+		WithContextExtractor(func(ctx context.Context) []Attr {
+			if tenant, ok := ctx.Value(tenantKey{}).(string); ok {
+				return []Attr{String("tenant", tenant)}
+			}
+			return nil
+		})
+	logger := GetBuilder().FromConfiguration(config)
+
+	ctx := ContextWithAttrs(context.Background(), String("request_id", "r-1"))
+	ctx = ContextWithAttrs(context.WithValue(ctx, tenantKey{}, "acme"), String("user_id", "u-1"))
+	logger.InfoContext(ctx, "handled")
+	logger.Info("plain")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	for _, want := range []string{`"r-1"`, `"u-1"`, `"acme"`} {
+		if !strings.Contains(lines[0], want) {
+			t.Fatalf("context attr %s is missing: %s", want, lines[0])
+		}
+	}
+	if strings.Contains(lines[1], "r-1") {
+		t.Fatalf("context attrs leak into records without context: %s", lines[1])
+	}
+}
+
+// TestHandlerForcedLevel tests that a forced level in the context enables debug records through Multi and async handlers
+// without lowering the configured level, and that Handle re-checks the level against the record.
+func TestHandlerForcedLevel(t *testing.T) {
+	var info, errs bytes.Buffer
+	builder := GetBuilder()
+	async := NewAsyncHandler(builder.Multi(
+		builder.FromConfiguration(GetConfigBuilder().Test().WithWriter(&info).WithLeveler(LevelInfo)),
+		builder.FromConfiguration(GetConfigBuilder().Test().WithWriter(&errs).WithLeveler(LevelError)),
+	).Handler(), nil)
+	logger := builder.FromHandler(async).WithGroup("checkout")
+
+	ctx := WithForcedLevel(context.Background(), LevelDebug)
+	if !logger.Enabled(ctx, LevelDebug) || logger.Enabled(context.Background(), LevelDebug) {
+		t.Fatal("Enabled() does not honor the forced level")
+	}
+	logger.DebugContext(ctx, "forced")
+	logger.DebugContext(context.Background(), "skipped")
+	if err := async.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	for _, out := range []string{info.String(), errs.String()} {
+		if !strings.Contains(out, "Forced") || strings.Contains(out, "Skipped") {
+			t.Fatalf("unexpected output: %s", out)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := newHandler(GetConfigBuilder().Test().WithWriter(&buf).WithLeveler(LevelInfo)).Handle(context.Background(), slog.NewRecord(time.Now(), LevelDebug, "direct", 0)); err != nil || buf.Len() != 0 {
+		t.Fatalf("Handle() does not re-check the level: %v %s", err, buf.String())
+	}
+}
@@ -0,0 +1,362 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	hashChainTrailer    = " #chain="     // 日志记录尾部的链值标记，格式为 " #chain=<seq>:<hash>"
+	hashChainCheckpoint = "#checkpoint " // 检查点行前缀，格式为 "#checkpoint seq=<seq> hash=<hash> time=<time> sig=<sig>"
+)
+
+var _ HashChainWriter = (*hashChainWriter)(nil)
+
+// HashChainOptions 是 HashChainWriter 及其校验器的选项，写入与校验时应使用相同的密钥
+type HashChainOptions struct {
+	key                []byte // 链值密钥，为空时使用 SHA-256，否则使用 HMAC-SHA256
+	checkpointKey      []byte // 检查点签名密钥，为空时使用链值密钥
+	checkpointInterval int    // 检查点间隔的日志记录数量
+	seq                uint64 // 起始序号
+	hash               []byte // 起始链值
+}
+
+// NewHashChainOptions 创建一个默认的 HashChainOptions
+//   - 默认使用 SHA-256 计算链值，每 1000 条日志记录写入一个检查点，起始链值为 32 字节的零值
+func NewHashChainOptions() *HashChainOptions {
+	return &HashChainOptions{
+		checkpointInterval: 1000,
+		hash:               make([]byte, sha256.Size),
+	}
+}
+
+// WithKey 设置链值密钥，设置后将使用 HMAC-SHA256 计算链值，没有密钥将无法伪造链值
+func (o *HashChainOptions) WithKey(key []byte) *HashChainOptions {
+	o.key = key
+	return o
+}
+
+// WithCheckpointKey 设置检查点签名密钥，为空时使用链值密钥
+func (o *HashChainOptions) WithCheckpointKey(key []byte) *HashChainOptions {
+	o.checkpointKey = key
+	return o
+}
+
+// WithCheckpointInterval 设置检查点间隔的日志记录数量，当 interval <= 0 时仅在调用 Checkpoint 时写入检查点
+func (o *HashChainOptions) WithCheckpointInterval(interval int) *HashChainOptions {
+	o.checkpointInterval = interval
+	return o
+}
+
+// WithPrevious 设置起始序号及链值，它被用于在重启或轮转后延续上一个文件的链
+//   - 可以通过 ResumeHashChain 从已有的文件中获取
+func (o *HashChainOptions) WithPrevious(seq uint64, hash []byte) *HashChainOptions {
+	o.seq = seq
+	o.hash = hash
+	return o
+}
+
+func (o *HashChainOptions) newHash() hash.Hash {
+	if len(o.key) == 0 {
+		return sha256.New()
+	}
+	return hmac.New(sha256.New, o.key)
+}
+
+func (o *HashChainOptions) chain(prev []byte, seq uint64, content []byte) []byte {
+	var seqBytes [8]byte
+	binary.BigEndian.PutUint64(seqBytes[:], seq)
+	h := o.newHash()
+	h.Write(prev)
+	h.Write(seqBytes[:])
+	h.Write(content)
+	return h.Sum(nil)
+}
+
+func (o *HashChainOptions) sign(seq uint64, hash []byte, t string) string {
+	key := o.checkpointKey
+	if len(key) == 0 {
+		key = o.key
+	}
+	if len(key) == 0 {
+		return ""
+	}
+	mac := hmac.New(sha256.New, key)
+	_, _ = fmt.Fprintf(mac, "%d|%x|%s", seq, hash, t)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// HashChainWriter 是一个防篡改的日志写入器，它会为每一条日志记录追加滚动的链值，并周期性的写入签名的检查点
+//   - 每一次 Write 被视为一条日志记录，链值为 H(上一个链值 || 序号 || 日志记录)
+//   - 任何日志记录的修改、删除或重排都会导致后续的链值无法通过校验
+type HashChainWriter interface {
+	io.Writer
+
+	// Checkpoint 立即写入一个检查点
+	Checkpoint() error
+
+	// Head 获取当前的序号及链值
+	Head() (seq uint64, hash []byte)
+}
+
+// NewHashChainWriter 创建一个防篡改的日志写入器，当 options 为 nil 时将使用默认选项
+func NewHashChainWriter(writer io.Writer, options *HashChainOptions) HashChainWriter {
+	if options == nil {
+		options = NewHashChainOptions()
+	}
+	return &hashChainWriter{
+		writer:  writer,
+		options: options,
+		seq:     options.seq,
+		hash:    options.hash,
+	}
+}
+
+type hashChainWriter struct {
+	rw        sync.Mutex
+	writer    io.Writer         // 日志写入器
+	options   *HashChainOptions // 选项
+	seq       uint64            // 当前序号
+	hash      []byte            // 当前链值
+	unchecked int               // 自上一个检查点以来的日志记录数量
+}
+
+func (w *hashChainWriter) Write(p []byte) (n int, err error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	content := bytes.TrimSuffix(p, []byte{'\n'})
+
+	w.rw.Lock()
+	defer w.rw.Unlock()
+
+	seq := w.seq + 1
+	chain := w.options.chain(w.hash, seq, content)
+
+	line := make([]byte, 0, len(content)+len(hashChainTrailer)+24+hex.EncodedLen(len(chain))+1)
+	line = append(line, content...)
+	line = append(line, hashChainTrailer...)
+	line = strconv.AppendUint(line, seq, 10)
+	line = append(line, ':')
+	line = hex.AppendEncode(line, chain)
+	line = append(line, '\n')
+	if _, err = w.writer.Write(line); err != nil {
+		return 0, err
+	}
+	w.seq, w.hash = seq, chain
+
+	w.unchecked++
+	if w.options.checkpointInterval > 0 && w.unchecked >= w.options.checkpointInterval {
+		if err = w.checkpoint(); err != nil {
+			return len(p), err
+		}
+	}
+	return len(p), nil
+}
+
+func (w *hashChainWriter) Checkpoint() error {
+	w.rw.Lock()
+	defer w.rw.Unlock()
+	return w.checkpoint()
+}
+
+func (w *hashChainWriter) checkpoint() error {
+	t := time.Now().UTC().Format(time.RFC3339Nano)
+	line := fmt.Sprintf("%sseq=%d hash=%x time=%s sig=%s\n", hashChainCheckpoint, w.seq, w.hash, t, w.options.sign(w.seq, w.hash, t))
+	if _, err := io.WriteString(w.writer, line); err != nil {
+		return err
+	}
+	w.unchecked = 0
+	return nil
+}
+
+func (w *hashChainWriter) Head() (seq uint64, hash []byte) {
+	w.rw.Lock()
+	defer w.rw.Unlock()
+	return w.seq, append([]byte(nil), w.hash...)
+}
+
+// HashChainReport 是哈希链校验的结果
+type HashChainReport struct {
+	Records     uint64          // 通过校验的日志记录数量
+	Checkpoints int             // 通过校验的检查点数量
+	Seq         uint64          // 最后一条通过校验的日志记录序号
+	Hash        []byte          // 最后一条通过校验的日志记录链值
+	Broken      *HashChainBreak // 第一个断裂的位置，为 nil 时表示校验通过
+}
+
+// HashChainBreak 描述了哈希链第一个断裂的位置
+type HashChainBreak struct {
+	File   string // 文件名
+	Line   int    // 行号，从 1 开始
+	Seq    uint64 // 期望的序号
+	Reason string // 断裂原因
+}
+
+func (b *HashChainBreak) Error() string {
+	return fmt.Sprintf("hash chain broken at %s:%d (seq %d): %s", b.File, b.Line, b.Seq, b.Reason)
+}
+
+// VerifyHashChain 校验 reader 中的哈希链，name 仅用于报告断裂位置
+func VerifyHashChain(options *HashChainOptions, name string, reader io.Reader) (*HashChainReport, error) {
+	if options == nil {
+		options = NewHashChainOptions()
+	}
+	report := &HashChainReport{Seq: options.seq, Hash: options.hash}
+	return report, verifyHashChain(options, report, name, reader)
+}
+
+// VerifyHashChainFiles 按顺序校验一组文件的哈希链，例如轮转后的日志文件，后一个文件将延续前一个文件的链
+func VerifyHashChainFiles(options *HashChainOptions, paths ...string) (*HashChainReport, error) {
+	if options == nil {
+		options = NewHashChainOptions()
+	}
+	report := &HashChainReport{Seq: options.seq, Hash: options.hash}
+	for _, path := range paths {
+		if err := verifyHashChainFile(options, report, path); err != nil {
+			return report, err
+		}
+		if report.Broken != nil {
+			break
+		}
+	}
+	return report, nil
+}
+
+// ResumeHashChain 获取指定文件中最后一条通过校验的序号及链值，它被用于在重启后延续链
+//   - 如果文件不存在，将返回 options 中的起始序号及链值
+func ResumeHashChain(options *HashChainOptions, path string) (seq uint64, hash []byte, err error) {
+	if options == nil {
+		options = NewHashChainOptions()
+	}
+	report := &HashChainReport{Seq: options.seq, Hash: options.hash}
+	if err = verifyHashChainFile(options, report, path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return options.seq, options.hash, nil
+		}
+		return 0, nil, err
+	}
+	if report.Broken != nil {
+		return 0, nil, report.Broken
+	}
+	return report.Seq, report.Hash, nil
+}
+
+func verifyHashChainFile(options *HashChainOptions, report *HashChainReport, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return verifyHashChain(options, report, path, f)
+}
+
+func verifyHashChain(options *HashChainOptions, report *HashChainReport, name string, reader io.Reader) error {
+	r := bufio.NewReader(reader)
+	var (
+		pending   []byte // 尚未遇到链值标记的多行日志记录
+		startLine int    // 当前日志记录的起始行号
+		lineNo    int
+	)
+	broken := func(line int, reason string) {
+		report.Broken = &HashChainBreak{File: name, Line: line, Seq: report.Seq + 1, Reason: reason}
+	}
+
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(line) == 0 && err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+		lineNo++
+		line = bytes.TrimSuffix(line, []byte{'\n'})
+
+		if pending == nil && bytes.HasPrefix(line, []byte(hashChainCheckpoint)) {
+			if reason := verifyHashChainCheckpoint(options, report, string(line)); reason != "" {
+				broken(lineNo, reason)
+				return nil
+			}
+			report.Checkpoints++
+			continue
+		}
+
+		if pending == nil {
+			startLine = lineNo
+		}
+		content, seq, hash, ok := parseHashChainLine(line)
+		if !ok {
+			pending = append(append(pending, line...), '\n')
+			continue
+		}
+
+		content = append(pending, content...)
+		pending = nil
+		if seq != report.Seq+1 {
+			broken(startLine, fmt.Sprintf("unexpected sequence %d", seq))
+			return nil
+		}
+		expected := options.chain(report.Hash, seq, content)
+		if hex.EncodeToString(expected) != hash {
+			broken(startLine, "chain value mismatch")
+			return nil
+		}
+		report.Records++
+		report.Seq, report.Hash = seq, expected
+	}
+
+	if pending != nil {
+		broken(startLine, "incomplete record without chain value")
+	}
+	return nil
+}
+
+// parseHashChainLine 解析以链值标记结尾的行，当行尾不是合法的链值标记时返回 false
+func parseHashChainLine(line []byte) (content []byte, seq uint64, hash string, ok bool) {
+	idx := bytes.LastIndex(line, []byte(hashChainTrailer))
+	if idx < 0 {
+		return nil, 0, "", false
+	}
+	seqStr, hash, ok := strings.Cut(string(line[idx+len(hashChainTrailer):]), ":")
+	if !ok || len(hash) != hex.EncodedLen(sha256.Size) {
+		return nil, 0, "", false
+	}
+	seq, err := strconv.ParseUint(seqStr, 10, 64)
+	if err != nil {
+		return nil, 0, "", false
+	}
+	return line[:idx], seq, hash, true
+}
+
+func verifyHashChainCheckpoint(options *HashChainOptions, report *HashChainReport, line string) (reason string) {
+	fields := make(map[string]string)
+	for _, field := range strings.Fields(strings.TrimPrefix(line, hashChainCheckpoint)) {
+		k, v, _ := strings.Cut(field, "=")
+		fields[k] = v
+	}
+	seq, err := strconv.ParseUint(fields["seq"], 10, 64)
+	if err != nil {
+		return "malformed checkpoint"
+	}
+	if seq != report.Seq || fields["hash"] != hex.EncodeToString(report.Hash) {
+		return "checkpoint does not match chain"
+	}
+	if !hmac.Equal([]byte(fields["sig"]), []byte(options.sign(seq, report.Hash, fields["time"]))) {
+		return "checkpoint signature mismatch"
+	}
+	return ""
+}
@@ -0,0 +1,78 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestHashChainWriter tests that a hash chained log verifies and that edits and removals are reported.
+func TestHashChainWriter(t *testing.T) {
+	var buf bytes.Buffer
+	options := NewHashChainOptions().WithKey([]byte("secret")).WithCheckpointInterval(2)
+	writer := NewHashChainWriter(&buf, options)
+	logger := GetBuilder().FromConfiguration(GetConfigBuilder().Test().WithWriter(writer))
+
+	logger.Info("first", "i", 1)
+	logger.Error("second", Err(errors.New("multi-line error track")))
+	logger.Info("third", "i", 3)
+
+	report, err := VerifyHashChain(options, "buf", bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("VerifyHashChain() error = %v", err)
+	}
+	if report.Broken != nil || report.Records != 3 || report.Checkpoints != 1 {
+		t.Fatalf("unexpected report: %+v, broken: %v", report, report.Broken)
+	}
+
+	lines := strings.Split(buf.String(), "\n")
+	edited := strings.Replace(buf.String(), "Third", "Thirt", 1)
+	report, _ = VerifyHashChain(options, "buf", strings.NewReader(edited))
+	if report.Broken == nil || report.Broken.Seq != 3 {
+		t.Fatalf("edit is not detected: %+v", report.Broken)
+	}
+
+	removed := strings.Join(append(lines[:0:0], lines[1:]...), "\n")
+	report, _ = VerifyHashChain(options, "buf", strings.NewReader(removed))
+	if report.Broken == nil || report.Broken.Line != 1 {
+		t.Fatalf("removal is not detected: %+v", report.Broken)
+	}
+}
+
+// TestHashChainWriterResume tests that a chain continues across rotated files.
+func TestHashChainWriterResume(t *testing.T) {
+	dir := t.TempDir()
+	first, second := filepath.Join(dir, "app.log.1"), filepath.Join(dir, "app.log")
+
+	write := func(path string, options *HashChainOptions, msg string) {
+		f, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		_, _ = NewHashChainWriter(f, options).Write([]byte(msg + "\n"))
+	}
+
+	write(first, NewHashChainOptions(), "first")
+	seq, hash, err := ResumeHashChain(nil, first)
+	if err != nil {
+		t.Fatalf("ResumeHashChain() error = %v", err)
+	}
+	write(second, NewHashChainOptions().WithPrevious(seq, hash), "second")
+
+	report, err := VerifyHashChainFiles(nil, first, second)
+	if err != nil {
+		t.Fatalf("VerifyHashChainFiles() error = %v", err)
+	}
+	if report.Broken != nil || report.Records != 2 {
+		t.Fatalf("unexpected report: %+v, broken: %v", report, report.Broken)
+	}
+
+	report, _ = VerifyHashChainFiles(nil, second)
+	if report.Broken == nil {
+		t.Fatal("missing first file is not detected")
+	}
+}
@@ -0,0 +1,95 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// ErrRecordVetoed 是日志记录被前置钩子否决时传递给后置钩子的错误
+var ErrRecordVetoed = errors.New("log: record vetoed by hook")
+
+// PreHandleHook 是在日志记录被格式化及写入前执行的钩子
+//   - group 是通过 WithGroup 构建的分组路径，例如 "payment.api"
+//   - 钩子可以修改 record，例如通过 record.AddAttrs 添加属性，当返回 false 时该日志记录将被否决且不会被写入
+//   - 钩子中不应使用同一个 Logger 记录日志，以避免递归
+type PreHandleHook func(ctx context.Context, group string, record *slog.Record) bool
+
+// PostHandleHook 是在日志记录被写入后执行的钩子
+//   - err 是写入日志记录时产生的错误，当日志记录被前置钩子否决时为 ErrRecordVetoed
+//   - 钩子中不应使用同一个 Logger 记录日志，以避免递归
+type PostHandleHook func(ctx context.Context, group string, record slog.Record, err error)
+
+type levelHook[H any] struct {
+	levels map[Level]struct{} // 钩子所作用的日志级别，为 nil 时作用于所有级别
+	hook   H
+}
+
+// levelHooks 是按照添加顺序排列的钩子，它在变更时总是返回新的切片，因此可以在副本间共享
+type levelHooks[H any] []levelHook[H]
+
+func (hooks levelHooks[H]) append(hook H, levels []Level) levelHooks[H] {
+	h := levelHook[H]{hook: hook}
+	if len(levels) > 0 {
+		h.levels = make(map[Level]struct{}, len(levels))
+		for _, level := range levels {
+			h.levels[level] = struct{}{}
+		}
+	}
+	return append(hooks[:len(hooks):len(hooks)], h)
+}
+
+func (hooks levelHooks[H]) filter(level Level) []H {
+	var matched []H
+	for _, hook := range hooks {
+		if hook.levels == nil {
+			matched = append(matched, hook.hook)
+		} else if _, exist := hook.levels[level]; exist {
+			matched = append(matched, hook.hook)
+		}
+	}
+	return matched
+}
+
+// runPreHandleHooks 依次执行前置钩子，钩子中的 panic 将被恢复并作为错误返回，此时日志记录将继续被处理
+func runPreHandleHooks(ctx context.Context, hooks []PreHandleHook, group string, record *slog.Record) (pass bool, err error) {
+	for _, hook := range hooks {
+		keep, hookErr := func() (keep bool, err error) {
+			defer func() {
+				if v := recover(); v != nil {
+					keep, err = true, hookPanicError(v)
+				}
+			}()
+			return hook(ctx, group, record), nil
+		}()
+		err = errors.Join(err, hookErr)
+		if !keep {
+			return false, err
+		}
+	}
+	return true, err
+}
+
+// runPostHandleHooks 依次执行后置钩子，钩子中的 panic 将被恢复并作为错误返回
+func runPostHandleHooks(ctx context.Context, hooks []PostHandleHook, group string, record slog.Record, outcome error) (err error) {
+	for _, hook := range hooks {
+		err = errors.Join(err, func() (err error) {
+			defer func() {
+				if v := recover(); v != nil {
+					err = hookPanicError(v)
+				}
+			}()
+			hook(ctx, group, record, outcome)
+			return nil
+		}())
+	}
+	return err
+}
+
+func hookPanicError(v any) error {
+	if err, ok := v.(error); ok {
+		return fmt.Errorf("log hook: recover from panic: %w", err)
+	}
+	return fmt.Errorf("log hook: recover from panic: %v", v)
+}
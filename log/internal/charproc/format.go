@@ -25,7 +25,7 @@ func Camel(str string) string {
 	var builder strings.Builder
 	vv := []rune(str)
 	for i := 0; i < len(vv); i++ {
-		if vv[i] == '_' {
+		if vv[i] == '_' && i+1 < len(vv) {
 			i++
 			if vv[i] >= 97 && vv[i] <= 122 {
 				vv[i] -= 32
@@ -39,3 +39,30 @@ func Camel(str string) string {
 	}
 	return builder.String()
 }
+
+// Snake 蛇形字符串，例如 userId 及 HTTPStatus 将分别被转换为 user_id 及 http_status，"-" 及空格将被视为 "_"
+func Snake(str string) string {
+	var builder strings.Builder
+	vv := []rune(str)
+	var last rune
+	for i, r := range vv {
+		switch {
+		case r == '-' || r == ' ':
+			r = '_'
+		case unicode.IsUpper(r):
+			if i > 0 && last != '_' {
+				prev := vv[i-1]
+				if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && i+1 < len(vv) && unicode.IsLower(vv[i+1])) {
+					builder.WriteRune('_')
+				}
+			}
+			r = unicode.ToLower(r)
+		}
+		if r == '_' && last == '_' {
+			continue
+		}
+		builder.WriteRune(r)
+		last = r
+	}
+	return builder.String()
+}
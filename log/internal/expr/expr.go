@@ -0,0 +1,67 @@
+// Package expr 实现了一个用于过滤日志记录的小型表达式语言，表达式仅能读取求值环境中的值，不存在任何副作用
+//
+// 表达式的语法及语义记录于 log.NewFilterHandler 的文档中，修改语法时应当同步更新该文档
+package expr
+
+import (
+	"fmt"
+)
+
+// Env 是表达式的求值环境
+type Env interface {
+	// Lookup 获取标识符的值，path 是以 "." 分割的标识符，当值不存在时返回 false
+	Lookup(path []string) (Value, bool)
+}
+
+// Config 是表达式的编译配置
+type Config struct {
+	Constants map[string]Value // 常量，例如日志级别名称
+	Roots     []string         // 允许的标识符，以 "." 结尾的标识符表示其下的任意路径，例如 "attrs."，为空时不进行限制
+}
+
+// SyntaxError 是表达式编译失败时返回的错误
+type SyntaxError struct {
+	Src string // 表达式
+	Pos int    // 错误在表达式中的字节偏移
+	Msg string // 错误信息
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("expr: %s at offset %d in %q", e.Msg, e.Pos, e.Src)
+}
+
+// Program 是编译后的表达式，它是并发安全的
+type Program struct {
+	src  string
+	root node
+}
+
+// Compile 编译表达式
+func Compile(src string, config Config) (*Program, error) {
+	tokens, err := lex(src)
+	if err == nil {
+		p := &parser{config: config, tokens: tokens}
+		var root node
+		if root, err = p.parseOr(); err == nil {
+			if t := p.peek(); t.kind != tokenEOF {
+				err = p.errorf(t, "unexpected %s", t.describe())
+			} else {
+				return &Program{src: src, root: root}, nil
+			}
+		}
+	}
+	if syntaxErr, ok := err.(*SyntaxError); ok {
+		syntaxErr.Src = src
+	}
+	return nil, err
+}
+
+// Eval 对表达式求值，并返回结果在布尔上下文中是否为真
+func (p *Program) Eval(env Env) bool {
+	return p.root.eval(env).Truthy()
+}
+
+// String 获取表达式的源码
+func (p *Program) String() string {
+	return p.src
+}
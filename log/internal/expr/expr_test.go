@@ -0,0 +1,72 @@
+package expr
+
+import (
+	"strings"
+	"testing"
+)
+
+type mapEnv map[string]Value
+
+func (e mapEnv) Lookup(path []string) (Value, bool) {
+	v, ok := e[strings.Join(path, ".")]
+	return v, ok
+}
+
+// TestEval tests operator precedence, comparisons, glob and regular expression matching, and identifiers containing '-'.
+func TestEval(t *testing.T) {
+	config := Config{
+		Constants: map[string]Value{"WARN": Number(4), "ERROR": Number(8)},
+		Roots:     []string{"level", "msg", "group", "attrs."},
+	}
+	env := mapEnv{
+		"level":              Number(0),
+		"msg":                String("charge created"),
+		"group":              String("payment.api"),
+		"attrs.amount":       Number(1500),
+		"attrs.ok":           Bool(true),
+		"attrs.x-request-id": String("abc"),
+	}
+	cases := map[string]bool{
+		`level >= WARN || (group ~ "payment.*" && attrs.amount > 1000)`: true,
+		`level >= WARN || group ~ "payment.*" && attrs.amount > 2000`:   false,
+		`!(level >= ERROR) && msg =~ "^charge"`:                         true,
+		`attrs.missing == null && attrs.missing != 1`:                   true,
+		`attrs.amount > "1000"`:                                         false,
+		`attrs.ok && group !~ "user.*"`:                                 true,
+		`attrs.amount == 1.5e3 && attrs.amount == 1_500`:                true,
+		`attrs.x-request-id == "abc" && level >-1`:                      true,
+	}
+	for src, want := range cases {
+		program, err := Compile(src, config)
+		if err != nil {
+			t.Fatalf("Compile(%q) error = %v", src, err)
+		}
+		if got := program.Eval(env); got != want {
+			t.Errorf("Eval(%q) = %v, want %v", src, got, want)
+		}
+	}
+}
+
+// TestCompileError tests that compile errors report the offending position.
+func TestCompileError(t *testing.T) {
+	config := Config{Roots: []string{"level", "attrs."}}
+	cases := map[string]int{
+		`level >= `:            9,
+		`(level > 1`:           10,
+		`level ~ 1`:            8,
+		`attrs.x =~ "("`:       8,
+		`user == "a"`:          0,
+		`level > 1 level`:      10,
+		`msg == "unterminated`: 7,
+	}
+	for src, pos := range cases {
+		_, err := Compile(src, config)
+		syntaxErr, ok := err.(*SyntaxError)
+		if !ok {
+			t.Fatalf("Compile(%q) error = %v, want *SyntaxError", src, err)
+		}
+		if syntaxErr.Pos != pos {
+			t.Errorf("Compile(%q) position = %d, want %d: %v", src, syntaxErr.Pos, pos, err)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind uint8
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenOperator
+	tokenLParen
+	tokenRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string // 原始文本，对于字符串则为解码后的内容
+	pos  int    // 在源码中的字节偏移
+}
+
+func (t token) describe() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of expression"
+	case tokenString:
+		return strconv.Quote(t.text)
+	default:
+		return fmt.Sprintf("%q", t.text)
+	}
+}
+
+// operators 按照长度从长到短排列，以保证最长匹配
+var operators = []string{"||", "&&", "==", "!=", "<=", ">=", "=~", "!~", "<", ">", "~", "!"}
+
+func lex(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		r, size := utf8.DecodeRuneInString(src[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", pos: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", pos: i})
+			i++
+		case r == '"':
+			end := i + 1
+			for end < len(src) && src[end] != '"' {
+				if src[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(src) {
+				return nil, &SyntaxError{Pos: i, Msg: "unterminated string"}
+			}
+			text, err := strconv.Unquote(src[i : end+1])
+			if err != nil {
+				return nil, &SyntaxError{Pos: i, Msg: "invalid string " + src[i:end+1]}
+			}
+			tokens = append(tokens, token{kind: tokenString, text: text, pos: i})
+			i = end + 1
+		case r >= '0' && r <= '9' || r == '-' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9':
+			end := i + 1
+			for end < len(src) && (src[end] >= '0' && src[end] <= '9' || strings.IndexByte("._eE", src[end]) >= 0 ||
+				(src[end] == '-' || src[end] == '+') && (src[end-1] == 'e' || src[end-1] == 'E')) {
+				end++
+			}
+			if _, err := strconv.ParseFloat(src[i:end], 64); err != nil {
+				return nil, &SyntaxError{Pos: i, Msg: "invalid number " + src[i:end]}
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: src[i:end], pos: i})
+			i = end
+		case r == '_' || unicode.IsLetter(r):
+			end := i
+			for end < len(src) {
+				r, size := utf8.DecodeRuneInString(src[end:])
+				// 表达式中不存在减法运算，因此 "-" 可以出现在标识符中，例如 attrs.x-request-id
+				if r != '_' && r != '.' && r != '-' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+					break
+				}
+				end += size
+			}
+			ident := src[i:end]
+			if strings.HasSuffix(ident, ".") || strings.Contains(ident, "..") {
+				return nil, &SyntaxError{Pos: i, Msg: "invalid identifier " + ident}
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: ident, pos: i})
+			i = end
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(src[i:], op) {
+					tokens = append(tokens, token{kind: tokenOperator, text: op, pos: i})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, &SyntaxError{Pos: i, Msg: fmt.Sprintf("unexpected character %q", r)}
+			}
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(src)}), nil
+}
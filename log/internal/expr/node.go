@@ -0,0 +1,107 @@
+package expr
+
+import (
+	"path"
+	"regexp"
+)
+
+type node interface {
+	eval(env Env) Value
+}
+
+type literalNode struct {
+	value Value
+}
+
+func (n *literalNode) eval(env Env) Value {
+	return n.value
+}
+
+type identNode struct {
+	path []string
+}
+
+func (n *identNode) eval(env Env) Value {
+	if value, exist := env.Lookup(n.path); exist {
+		return value
+	}
+	return Null()
+}
+
+type orNode struct {
+	left, right node
+}
+
+func (n *orNode) eval(env Env) Value {
+	return Bool(n.left.eval(env).Truthy() || n.right.eval(env).Truthy())
+}
+
+type andNode struct {
+	left, right node
+}
+
+func (n *andNode) eval(env Env) Value {
+	return Bool(n.left.eval(env).Truthy() && n.right.eval(env).Truthy())
+}
+
+type notNode struct {
+	operand node
+}
+
+func (n *notNode) eval(env Env) Value {
+	return Bool(!n.operand.eval(env).Truthy())
+}
+
+type compareNode struct {
+	op          string
+	left, right node
+}
+
+func (n *compareNode) eval(env Env) Value {
+	left, right := n.left.eval(env), n.right.eval(env)
+	switch n.op {
+	case "==":
+		return Bool(equal(left, right))
+	case "!=":
+		return Bool(!equal(left, right))
+	}
+	result, ok := compare(left, right)
+	if !ok || left.kind == KindNull || left.kind == KindBool {
+		return Bool(false)
+	}
+	switch n.op {
+	case "<":
+		return Bool(result < 0)
+	case "<=":
+		return Bool(result <= 0)
+	case ">":
+		return Bool(result > 0)
+	default:
+		return Bool(result >= 0)
+	}
+}
+
+type globNode struct {
+	operand node
+	pattern string
+	negate  bool
+}
+
+func (n *globNode) eval(env Env) Value {
+	value := n.operand.eval(env)
+	if value.kind == KindNull {
+		return Bool(n.negate)
+	}
+	matched, _ := path.Match(n.pattern, value.String())
+	return Bool(matched != n.negate)
+}
+
+type regexNode struct {
+	operand node
+	re      *regexp.Regexp
+}
+
+func (n *regexNode) eval(env Env) Value {
+	value := n.operand.eval(env)
+	return Bool(value.kind != KindNull && n.re.MatchString(value.String()))
+}
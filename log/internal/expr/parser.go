@@ -0,0 +1,191 @@
+package expr
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type parser struct {
+	config Config
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) errorf(t token, format string, args ...any) error {
+	return &SyntaxError{Pos: t.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) isOperator(ops ...string) bool {
+	t := p.peek()
+	if t.kind != tokenOperator {
+		return false
+	}
+	for _, op := range ops {
+		if t.text == op {
+			return true
+		}
+	}
+	return false
+}
+
+// parseOr 解析 or = and { "||" and }
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator("||") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseAnd 解析 and = not { "&&" not }
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator("&&") {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &andNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseNot 解析 not = "!" not | compare
+func (p *parser) parseNot() (node, error) {
+	if p.isOperator("!") {
+		p.next()
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{operand: operand}, nil
+	}
+	return p.parseCompare()
+}
+
+// parseCompare 解析 compare = operand [ op operand ]
+func (p *parser) parseCompare() (node, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	if !p.isOperator("==", "!=", "<", "<=", ">", ">=", "~", "!~", "=~") {
+		return left, nil
+	}
+	op := p.next()
+	switch op.text {
+	case "~", "!~":
+		pattern, err := p.parseStringLiteral(op)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = path.Match(pattern, ""); err != nil {
+			return nil, p.errorf(op, "invalid glob pattern %q", pattern)
+		}
+		return &globNode{operand: left, pattern: pattern, negate: op.text == "!~"}, nil
+	case "=~":
+		pattern, err := p.parseStringLiteral(op)
+		if err != nil {
+			return nil, err
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, p.errorf(op, "invalid regular expression %q: %v", pattern, err)
+		}
+		return &regexNode{operand: left, re: re}, nil
+	}
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	return &compareNode{op: op.text, left: left, right: right}, nil
+}
+
+func (p *parser) parseStringLiteral(op token) (string, error) {
+	t := p.next()
+	if t.kind != tokenString {
+		return "", p.errorf(t, "operator %s requires a string literal, found %s", op.text, t.describe())
+	}
+	return t.text, nil
+}
+
+// parseOperand 解析 operand = "(" or ")" | literal | identifier
+func (p *parser) parseOperand() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenLParen:
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, p.errorf(closing, "expected \")\", found %s", closing.describe())
+		}
+		return inner, nil
+	case tokenString:
+		return &literalNode{value: String(t.text)}, nil
+	case tokenNumber:
+		n, _ := strconv.ParseFloat(t.text, 64)
+		return &literalNode{value: Number(n)}, nil
+	case tokenIdent:
+		switch t.text {
+		case "true":
+			return &literalNode{value: Bool(true)}, nil
+		case "false":
+			return &literalNode{value: Bool(false)}, nil
+		case "null":
+			return &literalNode{value: Null()}, nil
+		}
+		if value, exist := p.config.Constants[t.text]; exist {
+			return &literalNode{value: value}, nil
+		}
+		ident := strings.Split(t.text, ".")
+		if len(p.config.Roots) > 0 && !p.validRoot(ident) {
+			return nil, p.errorf(t, "unknown identifier %q, expected one of %s", t.text, strings.Join(p.config.Roots, ", "))
+		}
+		return &identNode{path: ident}, nil
+	default:
+		return nil, p.errorf(t, "expected operand, found %s", t.describe())
+	}
+}
+
+// validRoot 判断标识符是否以允许的根开始，以 "." 结尾的根要求至少包含一级子路径
+func (p *parser) validRoot(ident []string) bool {
+	for _, root := range p.config.Roots {
+		if name, nested := strings.CutSuffix(root, "."); nested {
+			if ident[0] == name && len(ident) > 1 {
+				return true
+			}
+		} else if len(ident) == 1 && ident[0] == root {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package expr
+
+import (
+	"strconv"
+)
+
+// Kind 是值的类型
+type Kind uint8
+
+const (
+	KindNull   Kind = iota // 空值，例如不存在的属性
+	KindBool               // 布尔值
+	KindNumber             // 数值，所有数值均以 float64 表示
+	KindString             // 字符串
+)
+
+// Value 是表达式中的值
+type Value struct {
+	kind Kind
+	num  float64
+	str  string
+	b    bool
+}
+
+// Null 创建一个空值
+func Null() Value {
+	return Value{}
+}
+
+// Bool 创建一个布尔值
+func Bool(b bool) Value {
+	return Value{kind: KindBool, b: b}
+}
+
+// Number 创建一个数值
+func Number(n float64) Value {
+	return Value{kind: KindNumber, num: n}
+}
+
+// String 创建一个字符串
+func String(s string) Value {
+	return Value{kind: KindString, str: s}
+}
+
+// Kind 获取值的类型
+func (v Value) Kind() Kind {
+	return v.kind
+}
+
+// Truthy 判断值在布尔上下文中是否为真，其中 false、0、空字符串及空值为假
+func (v Value) Truthy() bool {
+	switch v.kind {
+	case KindBool:
+		return v.b
+	case KindNumber:
+		return v.num != 0
+	case KindString:
+		return v.str != ""
+	default:
+		return false
+	}
+}
+
+func (v Value) String() string {
+	switch v.kind {
+	case KindBool:
+		return strconv.FormatBool(v.b)
+	case KindNumber:
+		return strconv.FormatFloat(v.num, 'g', -1, 64)
+	case KindString:
+		return v.str
+	default:
+		return "null"
+	}
+}
+
+// compare 比较两个值，当类型不同或不可比较时 ok 为 false
+func compare(a, b Value) (result int, ok bool) {
+	if a.kind != b.kind {
+		return 0, false
+	}
+	switch a.kind {
+	case KindNumber:
+		switch {
+		case a.num < b.num:
+			return -1, true
+		case a.num > b.num:
+			return 1, true
+		}
+		return 0, true
+	case KindString:
+		switch {
+		case a.str < b.str:
+			return -1, true
+		case a.str > b.str:
+			return 1, true
+		}
+		return 0, true
+	case KindBool:
+		if a.b == b.b {
+			return 0, true
+		}
+		return 0, false
+	default:
+		return 0, true
+	}
+}
+
+func equal(a, b Value) bool {
+	result, ok := compare(a, b)
+	return ok && result == 0
+}
@@ -72,17 +72,34 @@ type Builder interface {
 
 	// Multi 构建一个多重日志记录器，它会将多个日志记录器组合在一起
 	Multi(loggers ...Logger) Logger
+
+	// MultiWith 以指定的选项构建一个多重日志记录器，当 options 为 nil 时将使用默认选项
+	//  - 可通过 Logger.Handler 获取的 MultiHandler 查看每个日志记录器的健康统计
+	MultiWith(options *MultiOptions, loggers ...Logger) Logger
+
+	// FromHandler 以指定的 Handler 构建一个日志记录器
+	FromHandler(handler Handler) Logger
 }
 
 type builder struct{}
 
 func (b *builder) Multi(loggers ...Logger) Logger {
+	return b.MultiWith(nil, loggers...)
+}
+
+func (b *builder) MultiWith(options *MultiOptions, loggers ...Logger) Logger {
 	handlers := make([]slog.Handler, 0, len(loggers))
 	for _, l := range loggers {
 		handlers = append(handlers, l.Handler())
 	}
 	return &logger{
-		slog: slog.New(newMultiHandler(handlers...)),
+		slog: slog.New(newMultiHandler(options, handlers...)),
+	}
+}
+
+func (b *builder) FromHandler(handler Handler) Logger {
+	return &logger{
+		slog: slog.New(handler),
 	}
 }
 
@@ -6,7 +6,11 @@ import (
 	"github.com/kercylan98/go-log/log/internal/options"
 	"io"
 	"os"
+	"path"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -127,6 +131,9 @@ type LoggerOptions interface {
 	// WithErrTrackLevel 设置错误追踪级别，只有在指定的级别下才会记录错误追踪
 	WithErrTrackLevel(levels ...Level) LoggerConfiguration
 
+	// WithoutErrTrackLevel 移除错误追踪级别
+	WithoutErrTrackLevel(levels ...Level) LoggerConfiguration
+
 	// WithMessageFormatter 设置消息格式化器
 	WithMessageFormatter(formatter MessageFormatter) LoggerConfiguration
 
@@ -164,6 +171,32 @@ type LoggerOptions interface {
 
 	// WithWriter 设置日志写入器
 	WithWriter(writer io.Writer) LoggerConfiguration
+
+	// WithGroupLevel 设置分组的日志级别，它将覆盖 WithLeveler 设置的日志级别，当 leveler 为 nil 时将移除该分组的日志级别
+	//  - pattern 将与 WithGroup 构建的分组路径进行匹配，例如 "database.orders"，它支持 path.Match 的通配符语法
+	//  - 通配符 * 可以匹配包含 "." 的任意字符序列，因此 "database.*" 同时匹配 "database.orders" 及 "database.orders.read"
+	//  - 当多个 pattern 同时匹配时，最长的 pattern 优先，长度相同时不包含通配符的 pattern 优先
+	WithGroupLevel(pattern string, leveler Leveler) LoggerConfiguration
+
+	// WithPreHandleHook 添加一个在日志记录被格式化及写入前执行的钩子，它仅作用于 levels 中的日志级别，当 levels 为空时作用于所有级别
+	//  - 钩子按照添加的顺序执行，钩子中的 panic 将被恢复，并作为 Handle 的错误返回，日志记录将继续被处理
+	WithPreHandleHook(hook PreHandleHook, levels ...Level) LoggerConfiguration
+
+	// WithPostHandleHook 添加一个在日志记录被写入后执行的钩子，它仅作用于 levels 中的日志级别，当 levels 为空时作用于所有级别
+	//  - 钩子按照添加的顺序执行，钩子中的 panic 将被恢复，并作为 Handle 的错误返回
+	WithPostHandleHook(hook PostHandleHook, levels ...Level) LoggerConfiguration
+
+	// WithContextExtractor 添加一个上下文提取器，它所提取的属性将被添加到通过 *Context 方法记录的日志中
+	//  - 提取器按照添加的顺序执行，通过 ContextWithAttrs 存入上下文中的属性无需注册提取器
+	WithContextExtractor(extractor ContextExtractor) LoggerConfiguration
+
+	// WithRecordLimits 设置日志记录的大小限制，当 limits 为 nil 时表示不限制
+	WithRecordLimits(limits *RecordLimits) LoggerConfiguration
+
+	// WithEnrichment 设置作为固定属性添加到日志记录中的静态元数据，当 fields 为空时将添加所有字段，值为空的字段将被忽略
+	//  - 属性在每次处理日志记录时读取，因此同样作用于已构建的日志记录器，它们位于 WithAttrs 添加的属性及日志记录的属性之前，例如：
+	//    WithEnrichment(CollectEnrichment(), EnrichHost, EnrichService, EnrichVersion)
+	WithEnrichment(enrichment Enrichment, fields ...EnrichField) LoggerConfiguration
 }
 
 type LoggerOptionsFetcher interface {
@@ -200,6 +233,9 @@ type LoggerOptionsFetcher interface {
 	// FetchErrTrackLevel 获取错误追踪级别
 	FetchErrTrackLevel(level Level) bool
 
+	// FetchErrTrackLevels 获取所有错误追踪级别，它们按照级别从低到高排序
+	FetchErrTrackLevels() []Level
+
 	// FetchDelimiter 获取分隔符
 	FetchDelimiter() string
 
@@ -211,6 +247,28 @@ type LoggerOptionsFetcher interface {
 
 	// FetchWriter 获取日志写入器
 	FetchWriter() io.Writer
+
+	// FetchGroupLevel 获取分组路径所匹配的日志级别，当没有匹配的 pattern 时返回 nil
+	//  - 匹配结果将被缓存，直到分组的日志级别发生变更
+	FetchGroupLevel(group string) Leveler
+
+	// FetchGroupLevels 获取所有分组的日志级别
+	FetchGroupLevels() map[string]Leveler
+
+	// FetchPreHandleHooks 获取作用于指定日志级别的前置钩子
+	FetchPreHandleHooks(level Level) []PreHandleHook
+
+	// FetchPostHandleHooks 获取作用于指定日志级别的后置钩子
+	FetchPostHandleHooks(level Level) []PostHandleHook
+
+	// FetchContextExtractors 获取上下文提取器
+	FetchContextExtractors() []ContextExtractor
+
+	// FetchRecordLimits 获取日志记录的大小限制，未设置时返回 nil
+	FetchRecordLimits() *RecordLimits
+
+	// FetchEnrichment 获取作为固定属性添加到日志记录中的静态元数据属性
+	FetchEnrichment() []Attr
 }
 
 type loggerConfiguration struct {
@@ -230,6 +288,47 @@ type loggerConfiguration struct {
 	errTrackLevel    map[Level]struct{}         // 错误追踪级别
 	trackBeautify    bool                       // 错误追踪美化
 	writer           io.Writer                  // 日志写入器
+	groupLevels      []groupLevel               // 分组的日志级别，按匹配优先级排序
+	groupLevelCache  *groupLevelCache           // 分组路径到日志级别的匹配缓存
+	preHandleHooks   levelHooks[PreHandleHook]  // 前置钩子
+	postHandleHooks  levelHooks[PostHandleHook] // 后置钩子
+	contextExtractor []ContextExtractor         // 上下文提取器
+	recordLimits     *RecordLimits              // 日志记录的大小限制
+	enrichment       []Attr                     // 静态元数据属性
+}
+
+type groupLevel struct {
+	pattern string
+	leveler Leveler
+}
+
+// groupLevelCacheSize 是分组路径匹配缓存的最大数量
+const groupLevelCacheSize = 4096
+
+// groupLevelCache 是分组路径到日志级别的匹配缓存，它在分组的日志级别变更时整体替换
+//   - 缓存的数量不超过 groupLevelCacheSize，超出后新的分组路径将不再被缓存，以免动态构建的分组路径导致内存无限增长
+type groupLevelCache struct {
+	entries sync.Map
+	size    atomic.Int64
+}
+
+func (c *groupLevelCache) load(group string) (Leveler, bool) {
+	v, exist := c.entries.Load(group)
+	if !exist {
+		return nil, false
+	}
+	leveler, _ := v.(Leveler)
+	return leveler, true
+}
+
+func (c *groupLevelCache) store(group string, leveler Leveler) {
+	if c.size.Add(1) > groupLevelCacheSize {
+		c.size.Add(-1)
+		return
+	}
+	if _, loaded := c.entries.LoadOrStore(group, leveler); loaded {
+		c.size.Add(-1)
+	}
 }
 
 func (h *loggerConfiguration) WithWriter(writer io.Writer) LoggerConfiguration {
@@ -275,6 +374,13 @@ func (h *loggerConfiguration) FetchCopy() (fetcher LoggerOptionsFetcher) {
 		errTrackLevel:    cloneMap(h.errTrackLevel),
 		trackBeautify:    h.trackBeautify,
 		writer:           h.writer,
+		groupLevels:      h.groupLevels,     // 分组的日志级别在变更时整体替换，因此可以共享
+		groupLevelCache:  h.groupLevelCache, // 匹配缓存与分组的日志级别一一对应
+		preHandleHooks:   h.preHandleHooks,  // 钩子在变更时整体替换，因此可以共享
+		postHandleHooks:  h.postHandleHooks,
+		contextExtractor: h.contextExtractor,
+		recordLimits:     h.recordLimits,
+		enrichment:       h.enrichment,
 	}
 
 	return clone
@@ -341,6 +447,19 @@ func (h *loggerConfiguration) FetchErrTrackLevel(level Level) bool {
 	return exist
 }
 
+func (h *loggerConfiguration) FetchErrTrackLevels() []Level {
+	h.rw.RLock()
+	defer h.rw.RUnlock()
+	levels := make([]Level, 0, len(h.errTrackLevel))
+	for level := range h.errTrackLevel {
+		levels = append(levels, level)
+	}
+	sort.Slice(levels, func(i, j int) bool {
+		return levels[i] < levels[j]
+	})
+	return levels
+}
+
 func (h *loggerConfiguration) FetchDelimiter() string {
 	h.rw.RLock()
 	defer h.rw.RUnlock()
@@ -384,6 +503,14 @@ func (h *loggerConfiguration) WithErrTrackLevel(levels ...Level) LoggerConfigura
 	})
 }
 
+func (h *loggerConfiguration) WithoutErrTrackLevel(levels ...Level) LoggerConfiguration {
+	return h.update(func(config *loggerConfiguration) {
+		for _, level := range levels {
+			delete(config.errTrackLevel, level)
+		}
+	})
+}
+
 func (h *loggerConfiguration) WithMessageFormatter(formatter MessageFormatter) LoggerConfiguration {
 	return h.update(func(config *loggerConfiguration) {
 		config.messageFormatter = formatter
@@ -466,3 +593,124 @@ func (h *loggerConfiguration) FetchTrackBeautify() bool {
 	defer h.rw.RUnlock()
 	return h.trackBeautify
 }
+
+func (h *loggerConfiguration) WithGroupLevel(pattern string, leveler Leveler) LoggerConfiguration {
+	return h.update(func(config *loggerConfiguration) {
+		levels := make([]groupLevel, 0, len(config.groupLevels)+1)
+		for _, level := range config.groupLevels {
+			if level.pattern != pattern {
+				levels = append(levels, level)
+			}
+		}
+		if leveler != nil {
+			levels = append(levels, groupLevel{pattern: pattern, leveler: leveler})
+		}
+		sort.SliceStable(levels, func(i, j int) bool {
+			a, b := levels[i].pattern, levels[j].pattern
+			if len(a) != len(b) {
+				return len(a) > len(b)
+			}
+			return !isGroupPattern(a) && isGroupPattern(b)
+		})
+		config.groupLevels = levels
+		config.groupLevelCache = new(groupLevelCache)
+	})
+}
+
+func (h *loggerConfiguration) FetchGroupLevel(group string) Leveler {
+	h.rw.RLock()
+	levels, cache := h.groupLevels, h.groupLevelCache
+	h.rw.RUnlock()
+	if len(levels) == 0 {
+		return nil
+	}
+
+	if leveler, exist := cache.load(group); exist {
+		return leveler
+	}
+	var leveler Leveler
+	for _, level := range levels {
+		if matched, _ := path.Match(level.pattern, group); matched {
+			leveler = level.leveler
+			break
+		}
+	}
+	cache.store(group, leveler)
+	return leveler
+}
+
+func (h *loggerConfiguration) FetchGroupLevels() map[string]Leveler {
+	h.rw.RLock()
+	defer h.rw.RUnlock()
+	levels := make(map[string]Leveler, len(h.groupLevels))
+	for _, level := range h.groupLevels {
+		levels[level.pattern] = level.leveler
+	}
+	return levels
+}
+
+// isGroupPattern 判断 pattern 是否包含通配符
+func isGroupPattern(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[\\")
+}
+
+func (h *loggerConfiguration) WithPreHandleHook(hook PreHandleHook, levels ...Level) LoggerConfiguration {
+	return h.update(func(config *loggerConfiguration) {
+		config.preHandleHooks = config.preHandleHooks.append(hook, levels)
+	})
+}
+
+func (h *loggerConfiguration) WithPostHandleHook(hook PostHandleHook, levels ...Level) LoggerConfiguration {
+	return h.update(func(config *loggerConfiguration) {
+		config.postHandleHooks = config.postHandleHooks.append(hook, levels)
+	})
+}
+
+func (h *loggerConfiguration) FetchPreHandleHooks(level Level) []PreHandleHook {
+	h.rw.RLock()
+	defer h.rw.RUnlock()
+	return h.preHandleHooks.filter(level)
+}
+
+func (h *loggerConfiguration) FetchPostHandleHooks(level Level) []PostHandleHook {
+	h.rw.RLock()
+	defer h.rw.RUnlock()
+	return h.postHandleHooks.filter(level)
+}
+
+func (h *loggerConfiguration) WithContextExtractor(extractor ContextExtractor) LoggerConfiguration {
+	return h.update(func(config *loggerConfiguration) {
+		config.contextExtractor = append(config.contextExtractor[:len(config.contextExtractor):len(config.contextExtractor)], extractor)
+	})
+}
+
+func (h *loggerConfiguration) FetchContextExtractors() []ContextExtractor {
+	h.rw.RLock()
+	defer h.rw.RUnlock()
+	return h.contextExtractor
+}
+
+func (h *loggerConfiguration) WithRecordLimits(limits *RecordLimits) LoggerConfiguration {
+	return h.update(func(config *loggerConfiguration) {
+		config.recordLimits = limits
+	})
+}
+
+func (h *loggerConfiguration) FetchRecordLimits() *RecordLimits {
+	h.rw.RLock()
+	defer h.rw.RUnlock()
+	return h.recordLimits
+}
+
+func (h *loggerConfiguration) WithEnrichment(enrichment Enrichment, fields ...EnrichField) LoggerConfiguration {
+	attrs := enrichment.Attrs(fields...)
+	return h.update(func(config *loggerConfiguration) {
+		config.enrichment = attrs[:len(attrs):len(attrs)]
+	})
+}
+
+func (h *loggerConfiguration) FetchEnrichment() []Attr {
+	h.rw.RLock()
+	defer h.rw.RUnlock()
+	return h.enrichment
+}
@@ -0,0 +1,253 @@
+package log
+
+import (
+	"bufio"
+	"context"
+	"expvar"
+	"fmt"
+	jsonIter "github.com/json-iterator/go"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	_ expvar.Var   = (*Metrics)(nil)
+	_ http.Handler = (*Metrics)(nil)
+	_ Handler      = (*metricsHandler)(nil)
+)
+
+// DroppedCounter 是能够统计被丢弃的日志记录数量的对象，例如 SamplingHandler、RateLimitHandler 及 AsyncHandler
+type DroppedCounter interface {
+	// Dropped 获取被丢弃的日志记录数量
+	Dropped() uint64
+}
+
+// Metrics 是日志的指标统计，它统计各级别及分组的日志记录数量、写入字节数、写入错误、处理器错误、处理器 panic 以及被丢弃的日志记录数量
+//   - 日志记录数量、处理器错误及 panic 由 NewMetricsHandler 包装的处理器统计，写入字节数及写入错误由 NewMetricsWriter 包装的写入器统计
+//   - 它实现了 expvar.Var，可以通过 expvar.Publish 发布，例如：expvar.Publish("log", metrics)
+//   - 它实现了 http.Handler，将以 Prometheus 文本格式输出指标，且不依赖 Prometheus 客户端库
+type Metrics struct {
+	namespace    string
+	records      sync.Map // metricsRecordKey -> *atomic.Uint64
+	writtenBytes atomic.Uint64
+	writeErrors  atomic.Uint64
+	handleErrors atomic.Uint64
+	panics       atomic.Uint64
+	droppedRW    sync.RWMutex
+	dropped      map[string][]DroppedCounter
+}
+
+// NewMetrics 创建一个指标统计，namespace 将作为 Prometheus 指标名称的前缀，当 namespace 为空时将使用 "go_log"
+func NewMetrics(namespace string) *Metrics {
+	if namespace == "" {
+		namespace = "go_log"
+	}
+	return &Metrics{
+		namespace: namespace,
+		dropped:   make(map[string][]DroppedCounter),
+	}
+}
+
+// RegisterDropped 注册一个被丢弃的日志记录数量的来源，相同 source 的多个来源将被累加
+func (m *Metrics) RegisterDropped(source string, counter DroppedCounter) *Metrics {
+	m.droppedRW.Lock()
+	defer m.droppedRW.Unlock()
+	m.dropped[source] = append(m.dropped[source], counter)
+	return m
+}
+
+// MetricsSnapshot 是 Metrics 在某一时刻的快照
+type MetricsSnapshot struct {
+	Records       map[string]map[string]uint64 `json:"records"`        // 各级别及分组的日志记录数量，未分组的日志记录以空字符串作为分组
+	WrittenBytes  uint64                       `json:"written_bytes"`  // 写入的字节数
+	WriteErrors   uint64                       `json:"write_errors"`   // 写入错误的次数
+	HandleErrors  uint64                       `json:"handle_errors"`  // 处理器返回错误的次数
+	HandlerPanics uint64                       `json:"handler_panics"` // 处理器发生 panic 的次数
+	Dropped       map[string]uint64            `json:"dropped"`        // 各来源被丢弃的日志记录数量
+}
+
+// Snapshot 获取指标统计的快照
+func (m *Metrics) Snapshot() MetricsSnapshot {
+	snapshot := MetricsSnapshot{
+		Records:       make(map[string]map[string]uint64),
+		WrittenBytes:  m.writtenBytes.Load(),
+		WriteErrors:   m.writeErrors.Load(),
+		HandleErrors:  m.handleErrors.Load(),
+		HandlerPanics: m.panics.Load(),
+		Dropped:       make(map[string]uint64),
+	}
+	m.records.Range(func(key, value any) bool {
+		k := key.(metricsRecordKey)
+		level := k.level.String()
+		if snapshot.Records[level] == nil {
+			snapshot.Records[level] = make(map[string]uint64)
+		}
+		snapshot.Records[level][k.group] += value.(*atomic.Uint64).Load()
+		return true
+	})
+
+	m.droppedRW.RLock()
+	defer m.droppedRW.RUnlock()
+	for source, counters := range m.dropped {
+		for _, counter := range counters {
+			snapshot.Dropped[source] += counter.Dropped()
+		}
+	}
+	return snapshot
+}
+
+// String 以 JSON 格式输出指标统计的快照，它实现了 expvar.Var
+func (m *Metrics) String() string {
+	data, err := jsonIter.Marshal(m.Snapshot())
+	if err != nil {
+		return "{}"
+	}
+	return string(data)
+}
+
+// ServeHTTP 以 Prometheus 文本格式输出指标统计
+func (m *Metrics) ServeHTTP(writer http.ResponseWriter, _ *http.Request) {
+	writer.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = m.WritePrometheus(writer)
+}
+
+// WritePrometheus 将指标统计以 Prometheus 文本格式写入到 writer 中
+func (m *Metrics) WritePrometheus(writer io.Writer) error {
+	snapshot := m.Snapshot()
+	w := bufio.NewWriter(writer)
+
+	name := m.namespace + "_records_total"
+	fmt.Fprintf(w, "# HELP %s Number of log records handled by level and group.\n# TYPE %s counter\n", name, name)
+	var keys []metricsRecordKey
+	m.records.Range(func(key, _ any) bool {
+		keys = append(keys, key.(metricsRecordKey))
+		return true
+	})
+	slices.SortFunc(keys, func(a, b metricsRecordKey) int {
+		if a.level != b.level {
+			return int(a.level - b.level)
+		}
+		return strings.Compare(a.group, b.group)
+	})
+	for _, k := range keys {
+		level := k.level.String()
+		fmt.Fprintf(w, "%s{level=\"%s\",group=\"%s\"} %d\n", name, prometheusLabel(level), prometheusLabel(k.group), snapshot.Records[level][k.group])
+	}
+
+	for _, counter := range []struct {
+		name, help string
+		value      uint64
+	}{
+		{"written_bytes_total", "Number of bytes written by log writers.", snapshot.WrittenBytes},
+		{"write_errors_total", "Number of failed log writes.", snapshot.WriteErrors},
+		{"handle_errors_total", "Number of errors returned by log handlers.", snapshot.HandleErrors},
+		{"handler_panics_total", "Number of panics recovered from log handlers.", snapshot.HandlerPanics},
+	} {
+		name = m.namespace + "_" + counter.name
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", name, counter.help, name, name, counter.value)
+	}
+
+	name = m.namespace + "_dropped_records_total"
+	fmt.Fprintf(w, "# HELP %s Number of log records dropped by source.\n# TYPE %s counter\n", name, name)
+	sources := make([]string, 0, len(snapshot.Dropped))
+	for source := range snapshot.Dropped {
+		sources = append(sources, source)
+	}
+	slices.Sort(sources)
+	for _, source := range sources {
+		fmt.Fprintf(w, "%s{source=\"%s\"} %d\n", name, prometheusLabel(source), snapshot.Dropped[source])
+	}
+	return w.Flush()
+}
+
+// prometheusLabel 转义 Prometheus 标签值
+func prometheusLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// counter 获取指定级别及分组的日志记录计数器
+func (m *Metrics) counter(level slog.Level, group string) *atomic.Uint64 {
+	key := metricsRecordKey{level: level, group: group}
+	if c, ok := m.records.Load(key); ok {
+		return c.(*atomic.Uint64)
+	}
+	c, _ := m.records.LoadOrStore(key, new(atomic.Uint64))
+	return c.(*atomic.Uint64)
+}
+
+type metricsRecordKey struct {
+	level slog.Level
+	group string
+}
+
+// NewMetricsHandler 创建一个包装 handler 的日志处理器，它将统计传入的日志记录数量、处理器返回的错误以及发生的 panic，panic 将被转换为错误返回
+func NewMetricsHandler(handler Handler, metrics *Metrics) Handler {
+	return &metricsHandler{metrics: metrics, handler: handler}
+}
+
+type metricsHandler struct {
+	metrics *Metrics     // 指标统计，它在所有派生的处理器间共享
+	handler slog.Handler // 被包装的处理器
+	group   string       // 通过 WithGroup 构建的分组路径
+}
+
+func (h *metricsHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *metricsHandler) Handle(ctx context.Context, record slog.Record) (err error) {
+	ctx = withCallerFrames(ctx)
+	ctx, record = resolveContextAttrs(ctx, record)
+	h.metrics.counter(record.Level, h.group).Add(1)
+	defer func() {
+		if v := recover(); v != nil {
+			h.metrics.panics.Add(1)
+			switch v := v.(type) {
+			case error:
+				err = fmt.Errorf("recover from panic: %w", v)
+			default:
+				err = fmt.Errorf("recover from panic: %v", v)
+			}
+		}
+		if err != nil {
+			h.metrics.handleErrors.Add(1)
+		}
+	}()
+	return h.handler.Handle(ctx, record)
+}
+
+func (h *metricsHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &metricsHandler{metrics: h.metrics, handler: h.handler.WithAttrs(attrs), group: h.group}
+}
+
+func (h *metricsHandler) WithGroup(name string) slog.Handler {
+	n := &metricsHandler{metrics: h.metrics, handler: h.handler.WithGroup(name), group: name}
+	if h.group != "" {
+		n.group = h.group + "." + name
+	}
+	return n
+}
+
+// NewMetricsWriter 创建一个包装 writer 的日志写入器，它将统计写入的字节数以及写入错误的次数
+func NewMetricsWriter(writer io.Writer, metrics *Metrics) io.Writer {
+	return &metricsWriter{metrics: metrics, writer: writer}
+}
+
+type metricsWriter struct {
+	metrics *Metrics
+	writer  io.Writer
+}
+
+func (w *metricsWriter) Write(p []byte) (n int, err error) {
+	n, err = w.writer.Write(p)
+	w.metrics.writtenBytes.Add(uint64(n))
+	if err != nil {
+		w.metrics.writeErrors.Add(1)
+	}
+	return n, err
+}
@@ -0,0 +1,44 @@
+package log
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestMetrics tests that records, written bytes and dropped records are exposed in the Prometheus text format and through expvar.
+func TestMetrics(t *testing.T) {
+	var buf bytes.Buffer
+	metrics := NewMetrics("")
+	inner := newHandler(GetConfigBuilder().Test().WithWriter(NewMetricsWriter(&buf, metrics)))
+	sampling := NewSamplingHandler(inner, NewSamplingOptions().WithFirst(1).WithThereafter(0))
+	metrics.RegisterDropped("sampling", sampling)
+
+	logger := GetBuilder().FromHandler(NewMetricsHandler(sampling, metrics))
+	logger.WithGroup("payment").Error("failed")
+	logger.Warn("warned")
+	logger.Info("sampled")
+	logger.Info("sampled")
+
+	recorder := httptest.NewRecorder()
+	metrics.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	out := recorder.Body.String()
+	for _, want := range []string{
+		`go_log_records_total{level="ERROR",group="payment"} 1`,
+		`go_log_records_total{level="INFO",group=""} 2`,
+		`go_log_records_total{level="WARN",group=""} 1`,
+		`go_log_dropped_records_total{source="sampling"} 1`,
+		"go_log_write_errors_total 0",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("metrics do not contain %q:\n%s", want, out)
+		}
+	}
+	if snapshot := metrics.Snapshot(); snapshot.WrittenBytes != uint64(buf.Len()) {
+		t.Fatalf("WrittenBytes = %d, want %d", snapshot.WrittenBytes, buf.Len())
+	}
+	if !strings.Contains(metrics.String(), `"dropped":{"sampling":1}`) {
+		t.Fatalf("unexpected expvar output: %s", metrics.String())
+	}
+}
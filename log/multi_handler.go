@@ -2,21 +2,94 @@ package log
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
-var _ slog.Handler = (*multiHandler)(nil)
+var _ MultiHandler = (*multiHandler)(nil)
 
-// newMultiHandler 创建一个新的多处理程序
-func newMultiHandler(handlers ...slog.Handler) slog.Handler {
+// ErrMultiTimeout 是多处理程序中的处理器处理超时时返回的错误
+var ErrMultiTimeout = errors.New("log multi handler timeout")
+
+// MultiOptions 是 Builder.MultiWith 的选项
+type MultiOptions struct {
+	names   []string      // 处理器名称，与日志记录器的顺序一一对应
+	timeout time.Duration // 单个处理器的处理超时时间
+}
+
+// NewMultiOptions 创建一个默认的 MultiOptions
+//   - 默认以索引标识处理器，不限制处理超时时间
+func NewMultiOptions() *MultiOptions {
+	return &MultiOptions{}
+}
+
+// WithNames 设置处理器名称，它们与日志记录器的顺序一一对应，名称将用于标识错误及健康统计，空名称将使用索引代替
+func (o *MultiOptions) WithNames(names ...string) *MultiOptions {
+	o.names = names
+	return o
+}
+
+// WithTimeout 设置单个处理器的处理超时时间，当 timeout <= 0 时表示不限制
+//   - 设置超时时间后，所有处理器将并发处理日志记录，超时的处理器不会阻塞其他处理器
+//   - 超时的处理不会被中断，但传入的 context.Context 将被取消，它可能在超时后仍然完成写入
+//   - 当处理器存在已超时但仍未完成的处理时，新的日志记录将不再交给该处理器，而是直接视为处理超时，直到这些处理完成
+//   - 未超时的并发处理不受上述限制，它们不会被视为处理超时
+func (o *MultiOptions) WithTimeout(timeout time.Duration) *MultiOptions {
+	o.timeout = timeout
+	return o
+}
+
+// MultiBranchStats 是多处理程序中单个处理器的健康统计
+type MultiBranchStats struct {
+	Name                string    // 处理器名称，未命名时为其索引
+	Handled             uint64    // 成功处理的日志记录数量
+	Failed              uint64    // 处理失败的日志记录数量，包含发生 panic 及处理超时的日志记录
+	Panics              uint64    // 发生 panic 的日志记录数量
+	Timeouts            uint64    // 处理超时的日志记录数量
+	ConsecutiveFailures uint64    // 连续处理失败的次数，成功处理后将被重置
+	LastError           error     // 最后一次处理失败的错误
+	LastErrorAt         time.Time // 最后一次处理失败的时间
+}
+
+// MultiHandler 是由 Builder.Multi 及 Builder.MultiWith 构建的日志记录器所使用的处理器
+//   - 每一个启用的处理器都会被尝试，单个处理器的错误或 panic 不会影响其他处理器
+//   - 所有处理器的错误将通过 errors.Join 合并，并以处理器名称或索引进行标识
+type MultiHandler interface {
+	Handler
+
+	// Stats 获取每个处理器的健康统计
+	Stats() []MultiBranchStats
+}
+
+// newMultiHandler 创建一个新的多处理程序，当 options 为 nil 时将使用默认选项
+func newMultiHandler(options *MultiOptions, handlers ...slog.Handler) *multiHandler {
+	if options == nil {
+		options = NewMultiOptions()
+	}
+	branches := make([]*multiBranch, len(handlers))
+	for i := range branches {
+		branches[i] = &multiBranch{stats: MultiBranchStats{Name: strconv.Itoa(i)}}
+		if i < len(options.names) && options.names[i] != "" {
+			branches[i].stats.Name = options.names[i]
+		}
+	}
 	return &multiHandler{
+		state: &multiState{
+			branches: branches,
+			timeout:  options.timeout,
+		},
 		handlers: handlers,
 	}
 }
 
 type multiHandler struct {
-	handlers []slog.Handler
+	state    *multiState    // 健康统计及选项，它在所有派生的处理器间共享
+	handlers []slog.Handler // 当前处理器所使用的处理器
 }
 
 func (h *multiHandler) Enabled(ctx context.Context, level slog.Level) bool {
@@ -29,29 +102,73 @@ func (h *multiHandler) Enabled(ctx context.Context, level slog.Level) bool {
 	return false
 }
 
-func (h *multiHandler) Handle(ctx context.Context, record slog.Record) (err error) {
-	for i := range h.handlers {
-		if h.handlers[i].Enabled(ctx, record.Level) {
-			err = func() error {
-				defer func() {
-					if v := recover(); v != nil {
-						switch v.(type) {
-						case error:
-							err = v.(error)
-						default:
-							err = fmt.Errorf("recover from panic: %v", v)
-						}
-					}
-				}()
-				return h.handlers[i].Handle(ctx, record.Clone())
-			}()
-			if err != nil {
-				return err
+func (h *multiHandler) Handle(ctx context.Context, record slog.Record) error {
+	ctx = withCallerFrames(ctx)
+	ctx, record = resolveContextAttrs(ctx, record)
+	if h.state.timeout <= 0 {
+		var errs []error
+		for i := range h.handlers {
+			if h.handlers[i].Enabled(ctx, record.Level) {
+				panicked, err := multiHandle(ctx, h.handlers[i], record.Clone())
+				errs = append(errs, h.state.report(i, err, panicked))
 			}
 		}
+		return errors.Join(errs...)
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), h.state.timeout)
+	defer cancel()
+
+	type result struct {
+		index    int
+		err      error
+		panicked bool
+	}
+	results := make(chan result, len(h.handlers))
+	pending := make(map[int]*atomic.Bool, len(h.handlers)) // 每个未完成的处理是否已被放弃
+	errs := make([]error, len(h.handlers))
+	for i := range h.handlers {
+		if !h.handlers[i].Enabled(ctx, record.Level) {
+			continue
+		}
+		branch := h.state.branches[i]
+		if branch.stuck.Load() > 0 {
+			// 此前超时的处理仍未完成
+			errs[i] = h.state.report(i, ErrMultiTimeout, false)
+			continue
+		}
+		abandoned := new(atomic.Bool)
+		pending[i] = abandoned
+		go func(i int, record slog.Record) {
+			panicked, err := multiHandle(ctx, h.handlers[i], record)
+			if !abandoned.CompareAndSwap(false, true) {
+				branch.stuck.Add(-1)
+			}
+			results <- result{index: i, err: err, panicked: panicked}
+		}(i, record.Clone())
 	}
 
-	return nil
+	timeout := ctx.Done()
+	for len(pending) > 0 {
+		select {
+		case r := <-results:
+			if _, exist := pending[r.index]; exist {
+				delete(pending, r.index)
+				errs[r.index] = h.state.report(r.index, r.err, r.panicked)
+			}
+		case <-timeout:
+			timeout = nil
+			for i, abandoned := range pending {
+				if abandoned.CompareAndSwap(false, true) {
+					h.state.branches[i].stuck.Add(1)
+					delete(pending, i)
+					errs[i] = h.state.report(i, ErrMultiTimeout, false)
+				}
+				// 否则处理恰好在超时时完成，等待其结果
+			}
+		}
+	}
+	return errors.Join(errs...)
 }
 
 func (h *multiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
@@ -59,7 +176,7 @@ func (h *multiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
 	for i, s := range h.handlers {
 		handlers[i] = s.WithAttrs(attrs)
 	}
-	return newMultiHandler(handlers...)
+	return &multiHandler{state: h.state, handlers: handlers}
 }
 
 func (h *multiHandler) WithGroup(name string) slog.Handler {
@@ -67,5 +184,66 @@ func (h *multiHandler) WithGroup(name string) slog.Handler {
 	for i, s := range h.handlers {
 		handlers[i] = s.WithGroup(name)
 	}
-	return newMultiHandler(handlers...)
+	return &multiHandler{state: h.state, handlers: handlers}
+}
+
+func (h *multiHandler) Stats() []MultiBranchStats {
+	stats := make([]MultiBranchStats, len(h.state.branches))
+	for i, branch := range h.state.branches {
+		branch.rw.Lock()
+		stats[i] = branch.stats
+		branch.rw.Unlock()
+	}
+	return stats
+}
+
+// multiHandle 使用处理器处理日志记录，并将 panic 转换为错误
+func multiHandle(ctx context.Context, handler slog.Handler, record slog.Record) (panicked bool, err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			panicked = true
+			switch v := v.(type) {
+			case error:
+				err = fmt.Errorf("recover from panic: %w", v)
+			default:
+				err = fmt.Errorf("recover from panic: %v", v)
+			}
+		}
+	}()
+	return false, handler.Handle(ctx, record)
+}
+
+type multiState struct {
+	branches []*multiBranch // 每个处理器的健康统计
+	timeout  time.Duration  // 单个处理器的处理超时时间
+}
+
+type multiBranch struct {
+	stuck atomic.Int32 // 已超时但仍未完成的处理数量
+	rw    sync.Mutex
+	stats MultiBranchStats
+}
+
+// report 记录处理器的处理结果，并返回以处理器名称标识的错误
+func (s *multiState) report(index int, err error, panicked bool) error {
+	branch := s.branches[index]
+	branch.rw.Lock()
+	defer branch.rw.Unlock()
+
+	if err == nil {
+		branch.stats.Handled++
+		branch.stats.ConsecutiveFailures = 0
+		return nil
+	}
+	branch.stats.Failed++
+	branch.stats.ConsecutiveFailures++
+	branch.stats.LastError = err
+	branch.stats.LastErrorAt = time.Now()
+	switch {
+	case panicked:
+		branch.stats.Panics++
+	case errors.Is(err, ErrMultiTimeout):
+		branch.stats.Timeouts++
+	}
+	return fmt.Errorf("log multi handler %s: %w", branch.stats.Name, err)
 }
@@ -0,0 +1,119 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type multiTestHandler struct {
+	slog.Handler
+	handle func(ctx context.Context) error
+}
+
+func (h *multiTestHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h *multiTestHandler) Handle(ctx context.Context, _ slog.Record) error {
+	return h.handle(ctx)
+}
+
+// TestMultiHandler tests that every branch is attempted, errors are tagged and joined, and stuck branches time out.
+func TestMultiHandler(t *testing.T) {
+	var buf bytes.Buffer
+	builder := GetBuilder()
+	sink := builder.FromConfiguration(GetConfigBuilder().Test().WithWriter(&buf))
+	failing := builder.FromHandler(&multiTestHandler{Handler: newSilentHandler(), handle: func(context.Context) error {
+		return errors.New("broken")
+	}})
+	panicking := builder.FromHandler(&multiTestHandler{Handler: newSilentHandler(), handle: func(context.Context) error {
+		panic("boom")
+	}})
+	stuck := builder.FromHandler(&multiTestHandler{Handler: newSilentHandler(), handle: func(ctx context.Context) error {
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	}})
+
+	multi := builder.MultiWith(NewMultiOptions().WithNames("broken", "", "", "stuck").WithTimeout(50*time.Millisecond), failing, panicking, sink, stuck)
+	handler := multi.Handler().(MultiHandler)
+	err := handler.Handle(context.Background(), slog.NewRecord(time.Now(), LevelInfo, "fan-out", 0))
+
+	if !strings.Contains(buf.String(), "Fan-out") {
+		t.Fatalf("healthy branch did not receive the record: %s", buf.String())
+	}
+	if err == nil || !errors.Is(err, ErrMultiTimeout) {
+		t.Fatalf("Handle() error = %v, want joined errors including ErrMultiTimeout", err)
+	}
+	for _, want := range []string{"log multi handler broken: broken", "log multi handler 1: recover from panic: boom", "log multi handler stuck: "} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("Handle() error = %v, want it to contain %q", err, want)
+		}
+	}
+
+	stats := handler.Stats()
+	if stats[0].Failed != 1 || stats[1].Panics != 1 || stats[2].Handled != 1 || stats[3].Timeouts != 1 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+// TestMultiHandlerBusyBranch tests that a branch still stuck on an earlier record is not handed new records.
+func TestMultiHandlerBusyBranch(t *testing.T) {
+	var calls atomic.Int64
+	release := make(chan struct{})
+	defer close(release)
+
+	builder := GetBuilder()
+	stuck := builder.FromHandler(&multiTestHandler{Handler: newSilentHandler(), handle: func(context.Context) error {
+		calls.Add(1)
+		<-release
+		return nil
+	}})
+	handler := builder.MultiWith(NewMultiOptions().WithTimeout(20*time.Millisecond), stuck).Handler().(MultiHandler)
+
+	for i := 0; i < 3; i++ {
+		err := handler.Handle(context.Background(), slog.NewRecord(time.Now(), LevelInfo, "busy", 0))
+		if !errors.Is(err, ErrMultiTimeout) {
+			t.Fatalf("Handle() error = %v, want ErrMultiTimeout", err)
+		}
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("busy branch was called %d times, want 1", calls.Load())
+	}
+	if stats := handler.Stats(); stats[0].Timeouts != 3 {
+		t.Fatalf("Timeouts = %d, want 3", stats[0].Timeouts)
+	}
+}
+
+// TestMultiHandlerConcurrent tests that concurrent records handled by a healthy branch within the timeout are not reported as timeouts.
+func TestMultiHandlerConcurrent(t *testing.T) {
+	builder := GetBuilder()
+	slow := builder.FromHandler(&multiTestHandler{Handler: newSilentHandler(), handle: func(context.Context) error {
+		time.Sleep(2 * time.Millisecond)
+		return nil
+	}})
+	handler := builder.MultiWith(NewMultiOptions().WithTimeout(time.Second), slow).Handler().(MultiHandler)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := handler.Handle(context.Background(), slog.NewRecord(time.Now(), LevelInfo, "concurrent", 0)); err != nil {
+				t.Errorf("Handle() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if stats := handler.Stats(); stats[0].Handled != 20 || stats[0].Timeouts != 0 {
+		t.Fatalf("Handled = %d, Timeouts = %d, want 20 and 0", stats[0].Handled, stats[0].Timeouts)
+	}
+}
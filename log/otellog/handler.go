@@ -0,0 +1,171 @@
+// Package otellog 提供了与 OpenTelemetry 链路追踪关联的日志处理器
+//   - 它是一个独立的模块，仅在引入该包时才会依赖 OpenTelemetry
+package otellog
+
+import (
+	"context"
+	"fmt"
+	"github.com/kercylan98/go-log/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"log/slog"
+	"time"
+)
+
+var _ log.Handler = (*spanHandler)(nil)
+
+// Options 是 NewHandler 的选项
+type Options struct {
+	traceIDKey    string      // trace_id 的属性键
+	spanIDKey     string      // span_id 的属性键
+	traceFlagsKey string      // trace_flags 的属性键
+	eventLeveler  log.Leveler // 记录为 Span 事件的日志级别
+	statusLeveler log.Leveler // 设置 Span 错误状态的日志级别
+}
+
+// NewOptions 创建一个默认的 Options
+//   - 默认以 trace_id、span_id 及 trace_flags 作为属性键，不记录 Span 事件，也不设置 Span 状态
+func NewOptions() *Options {
+	return &Options{
+		traceIDKey:    "trace_id",
+		spanIDKey:     "span_id",
+		traceFlagsKey: "trace_flags",
+	}
+}
+
+// WithKeys 设置 trace_id、span_id 及 trace_flags 的属性键，为空的键将不被添加
+func (o *Options) WithKeys(traceID, spanID, traceFlags string) *Options {
+	o.traceIDKey, o.spanIDKey, o.traceFlagsKey = traceID, spanID, traceFlags
+	return o
+}
+
+// WithSpanEvents 设置将日志记录为活跃 Span 事件的日志级别，当 leveler 为 nil 时不记录
+//   - 事件以日志消息命名，包含日志记录的属性，分组内的属性以 "." 连接
+func (o *Options) WithSpanEvents(leveler log.Leveler) *Options {
+	o.eventLeveler = leveler
+	return o
+}
+
+// WithErrorStatus 设置将活跃 Span 的状态设置为错误的日志级别，当 leveler 为 nil 时不设置
+//   - 日志记录中 error 类型的属性将同时通过 RecordError 记录到 Span 中
+func (o *Options) WithErrorStatus(leveler log.Leveler) *Options {
+	o.statusLeveler = leveler
+	return o
+}
+
+// NewHandler 创建一个包装 handler 的日志处理器，它会从 Handle 的上下文中读取活跃的 Span，当 options 为 nil 时将使用默认选项
+//   - 当 Span 有效时，日志记录将包含 trace_id、span_id 及 trace_flags 属性
+//   - 根据选项，日志记录还可以被记录为 Span 事件，或将 Span 的状态设置为错误
+func NewHandler(handler log.Handler, options *Options) log.Handler {
+	if options == nil {
+		options = NewOptions()
+	}
+	return &spanHandler{handler: handler, options: options}
+}
+
+type spanHandler struct {
+	handler slog.Handler // 被包装的处理器
+	options *Options
+	group   string // 通过 WithGroup 构建的分组路径
+}
+
+func (h *spanHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *spanHandler) Handle(ctx context.Context, record slog.Record) error {
+	ctx = log.WithCallerFrames(ctx)
+	span := trace.SpanFromContext(ctx)
+	sc := span.SpanContext()
+	if !sc.IsValid() {
+		return h.handler.Handle(ctx, record)
+	}
+
+	if span.IsRecording() {
+		if leveler := h.options.eventLeveler; leveler != nil && record.Level >= leveler.Level() {
+			span.AddEvent(record.Message, trace.WithTimestamp(eventTime(record)), trace.WithAttributes(h.eventAttributes(record)...))
+		}
+		if leveler := h.options.statusLeveler; leveler != nil && record.Level >= leveler.Level() {
+			record.Attrs(func(attr slog.Attr) bool {
+				if err, ok := attr.Value.Resolve().Any().(error); ok {
+					span.RecordError(err)
+				}
+				return true
+			})
+			span.SetStatus(codes.Error, record.Message)
+		}
+	}
+
+	record = record.Clone()
+	if key := h.options.traceIDKey; key != "" {
+		record.AddAttrs(slog.String(key, sc.TraceID().String()))
+	}
+	if key := h.options.spanIDKey; key != "" {
+		record.AddAttrs(slog.String(key, sc.SpanID().String()))
+	}
+	if key := h.options.traceFlagsKey; key != "" {
+		record.AddAttrs(slog.String(key, sc.TraceFlags().String()))
+	}
+	return h.handler.Handle(ctx, record)
+}
+
+func (h *spanHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &spanHandler{handler: h.handler.WithAttrs(attrs), options: h.options, group: h.group}
+}
+
+func (h *spanHandler) WithGroup(name string) slog.Handler {
+	n := &spanHandler{handler: h.handler.WithGroup(name), options: h.options, group: name}
+	if h.group != "" {
+		n.group = h.group + "." + name
+	}
+	return n
+}
+
+func eventTime(record slog.Record) time.Time {
+	if record.Time.IsZero() {
+		return time.Now()
+	}
+	return record.Time
+}
+
+// eventAttributes 将日志记录的级别、分组及属性转换为 Span 事件的属性
+func (h *spanHandler) eventAttributes(record slog.Record) []attribute.KeyValue {
+	kvs := []attribute.KeyValue{attribute.String("log.severity", record.Level.String())}
+	if h.group != "" {
+		kvs = append(kvs, attribute.String("log.group", h.group))
+	}
+	record.Attrs(func(attr slog.Attr) bool {
+		kvs = appendAttribute(kvs, "", attr)
+		return true
+	})
+	return kvs
+}
+
+func appendAttribute(kvs []attribute.KeyValue, prefix string, attr slog.Attr) []attribute.KeyValue {
+	key := attr.Key
+	if prefix != "" {
+		key = prefix + "." + key
+	}
+	if attr.Key == "" {
+		key = prefix
+	}
+	value := attr.Value.Resolve()
+	switch value.Kind() {
+	case slog.KindGroup:
+		for _, a := range value.Group() {
+			kvs = appendAttribute(kvs, key, a)
+		}
+		return kvs
+	case slog.KindBool:
+		return append(kvs, attribute.Bool(key, value.Bool()))
+	case slog.KindInt64:
+		return append(kvs, attribute.Int64(key, value.Int64()))
+	case slog.KindUint64:
+		return append(kvs, attribute.String(key, fmt.Sprint(value.Uint64())))
+	case slog.KindFloat64:
+		return append(kvs, attribute.Float64(key, value.Float64()))
+	default:
+		return append(kvs, attribute.String(key, value.String()))
+	}
+}
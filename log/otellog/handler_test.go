@@ -0,0 +1,55 @@
+package otellog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/kercylan98/go-log/log"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"strings"
+	"testing"
+)
+
+// TestHandler tests that records carry the span context and are recorded as span events with an error status.
+func TestHandler(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	defer func() { _ = provider.Shutdown(context.Background()) }()
+
+	var buf bytes.Buffer
+	base := log.GetBuilder().FromConfiguration(log.GetConfigBuilder().Test().WithWriter(&buf)).Handler()
+	options := NewOptions().WithKeys("trace_id", "span_id", "").WithSpanEvents(log.LevelInfo).WithErrorStatus(log.LevelError)
+	logger := log.GetBuilder().FromHandler(NewHandler(base, options))
+
+	ctx, span := provider.Tracer("test").Start(context.Background(), "request")
+	logger.WithGroup("payment").InfoContext(ctx, "charged", "amount", 100)
+	logger.ErrorContext(ctx, "failed", log.Err(errors.New("declined")))
+	logger.Info("untraced")
+	span.End()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	traceID := span.SpanContext().TraceID().String()
+	if !strings.Contains(lines[0], traceID) || !strings.Contains(lines[0], span.SpanContext().SpanID().String()) {
+		t.Fatalf("span context is missing: %s", lines[0])
+	}
+	if strings.Contains(lines[0], "trace_flags") || strings.Contains(lines[len(lines)-1], traceID) {
+		t.Fatalf("unexpected output: %s", buf.String())
+	}
+	if !strings.Contains(lines[0], "handler_test.go") {
+		t.Fatalf("caller is not resolved through the handler: %s", lines[0])
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("spans = %d, want 1", len(spans))
+	}
+	events := spans[0].Events
+	if len(events) != 3 || events[0].Name != "charged" || events[2].Name != "exception" {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+	if spans[0].Status.Code != codes.Error || spans[0].Status.Description != "failed" {
+		t.Fatalf("unexpected status: %+v", spans[0].Status)
+	}
+}
@@ -0,0 +1,371 @@
+package log
+
+import (
+	"cmp"
+	"container/list"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var _ RateLimitHandler = (*rateLimitHandler)(nil)
+
+// RateLimitOptions 是 RateLimitHandler 的选项
+type RateLimitOptions struct {
+	keyFields      []string      // 组成键的字段
+	rate           float64       // 每秒产生的令牌数量
+	burst          int           // 令牌桶容量
+	maxKeys        int           // 最多保留的键数量
+	reportInterval time.Duration // 摘要间隔
+}
+
+// NewRateLimitOptions 创建一个默认的 RateLimitOptions
+//   - 默认以 "level" 作为键，每个键每秒允许 100 条日志记录，突发容量为 100，最多保留 10000 个键，每分钟记录一次摘要
+func NewRateLimitOptions() *RateLimitOptions {
+	return &RateLimitOptions{
+		keyFields:      []string{"level"},
+		rate:           100,
+		burst:          100,
+		maxKeys:        10000,
+		reportInterval: time.Minute,
+	}
+}
+
+// WithKeyFields 设置组成键的字段，每个不同的键将拥有独立的令牌桶，未设置任何字段时所有日志记录共享一个令牌桶
+//   - level：日志级别
+//   - group：通过 WithGroup 构建的分组路径，例如 "payment.api"
+//   - attrs.<key>：日志记录或 WithAttrs 中指定键的属性值，例如 "attrs.tenant_id"，分组内的属性以 "." 连接，例如 "attrs.http.status"
+//
+// 例如 WithKeyFields("group", "attrs.tenant_id") 表示为每个分组下的每个租户单独限流
+func (o *RateLimitOptions) WithKeyFields(fields ...string) *RateLimitOptions {
+	o.keyFields = fields
+	return o
+}
+
+// WithRate 设置每个键每秒允许的日志记录数量及突发容量
+func (o *RateLimitOptions) WithRate(rate float64, burst int) *RateLimitOptions {
+	o.rate = rate
+	o.burst = burst
+	return o
+}
+
+// WithMaxKeys 设置最多保留的键数量，超出时将淘汰最久未使用的键
+func (o *RateLimitOptions) WithMaxKeys(limit int) *RateLimitOptions {
+	o.maxKeys = limit
+	return o
+}
+
+// WithReportInterval 设置摘要间隔，每个间隔结束后将为存在丢弃的键合并记录一条摘要
+func (o *RateLimitOptions) WithReportInterval(interval time.Duration) *RateLimitOptions {
+	o.reportInterval = interval
+	return o
+}
+
+// RateLimitHandler 是一个基于令牌桶的限流日志处理器，它会根据组成键的字段为每个键分配独立的令牌桶
+//   - 键使用有界的 LRU 存储，摘要在间隔结束后的下一条日志记录到达时记录
+//   - 摘要包含丢弃的总数量、存在丢弃的键数量，以及丢弃数量最多的前 10 个键
+type RateLimitHandler interface {
+	Handler
+
+	// Dropped 获取被丢弃的日志记录数量
+	Dropped() uint64
+}
+
+// NewRateLimitHandler 创建一个包装 handler 的限流日志处理器，当 options 为 nil 时将使用默认选项
+func NewRateLimitHandler(handler Handler, options *RateLimitOptions) (RateLimitHandler, error) {
+	if options == nil {
+		options = NewRateLimitOptions()
+	}
+	fields, err := parseRateLimitKeyFields(options.keyFields)
+	if err != nil {
+		return nil, err
+	}
+	return &rateLimitHandler{
+		state: &rateLimitState{
+			handler:        handler,
+			fields:         fields,
+			rate:           options.rate,
+			burst:          float64(max(options.burst, 1)),
+			maxKeys:        max(options.maxKeys, 1),
+			reportInterval: options.reportInterval,
+			buckets:        make(map[string]*list.Element),
+			lru:            list.New(),
+		},
+		handler: handler,
+	}, nil
+}
+
+type rateLimitField struct {
+	name string   // 字段名称：level、group 或 attrs
+	path []string // 属性路径
+}
+
+func parseRateLimitKeyFields(keyFields []string) ([]rateLimitField, error) {
+	var fields []rateLimitField
+	for _, field := range keyFields {
+		field = strings.TrimSpace(field)
+		switch {
+		case field == "level" || field == "group":
+			fields = append(fields, rateLimitField{name: field})
+		case strings.HasPrefix(field, "attrs.") && len(field) > len("attrs."):
+			fields = append(fields, rateLimitField{name: "attrs", path: strings.Split(field[len("attrs."):], ".")})
+		default:
+			return nil, fmt.Errorf("log rate limit: invalid key field %q", field)
+		}
+	}
+	return fields, nil
+}
+
+type rateLimitHandler struct {
+	state   *rateLimitState // 限流状态，它在所有派生的处理器间共享
+	handler slog.Handler    // 被包装的处理器
+	attrs   []slog.Attr     // 通过 WithAttrs 累积的属性
+	group   string          // 通过 WithGroup 构建的分组路径
+}
+
+func (h *rateLimitHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *rateLimitHandler) Handle(ctx context.Context, record slog.Record) error {
+	ctx = withCallerFrames(ctx)
+	ctx, record = resolveContextAttrs(ctx, record)
+	if !h.state.allow(h.key(record), record.Time) {
+		return nil
+	}
+	return h.handler.Handle(ctx, record)
+}
+
+func (h *rateLimitHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &rateLimitHandler{
+		state:   h.state,
+		handler: h.handler.WithAttrs(attrs),
+		attrs:   append(h.attrs[:len(h.attrs):len(h.attrs)], attrs...),
+		group:   h.group,
+	}
+}
+
+func (h *rateLimitHandler) WithGroup(name string) slog.Handler {
+	n := &rateLimitHandler{state: h.state, handler: h.handler.WithGroup(name), attrs: h.attrs, group: name}
+	if h.group != "" {
+		n.group = h.group + "." + name
+	}
+	return n
+}
+
+func (h *rateLimitHandler) Dropped() uint64 {
+	return h.state.dropped.Load()
+}
+
+// key 根据组成键的字段计算日志记录的键
+func (h *rateLimitHandler) key(record slog.Record) string {
+	var builder strings.Builder
+	for i, field := range h.state.fields {
+		if i > 0 {
+			builder.WriteByte(' ')
+		}
+		switch field.name {
+		case "level":
+			builder.WriteString("level=")
+			builder.WriteString(record.Level.String())
+		case "group":
+			builder.WriteString("group=")
+			builder.WriteString(h.group)
+		default:
+			builder.WriteString(strings.Join(field.path, "."))
+			builder.WriteByte('=')
+			if v, ok := findRecordAttr(h.attrs, record, field.path); ok {
+				builder.WriteString(v.String())
+			}
+		}
+	}
+	return builder.String()
+}
+
+// findRecordAttr 在日志记录及 WithAttrs 累积的属性中查找指定路径的属性值，日志记录中的属性优先
+func findRecordAttr(attrs []slog.Attr, record slog.Record, path []string) (value slog.Value, found bool) {
+	record.Attrs(func(attr slog.Attr) bool {
+		value, found = findAttr(attr, path)
+		return !found
+	})
+	for i := len(attrs) - 1; i >= 0 && !found; i-- {
+		value, found = findAttr(attrs[i], path)
+	}
+	return value, found
+}
+
+func findAttr(attr slog.Attr, path []string) (slog.Value, bool) {
+	if attr.Key == "" && attr.Value.Kind() == slog.KindGroup {
+		for _, a := range attr.Value.Group() {
+			if v, ok := findAttr(a, path); ok {
+				return v, true
+			}
+		}
+		return slog.Value{}, false
+	}
+	if attr.Key != path[0] {
+		return slog.Value{}, false
+	}
+	value := attr.Value.Resolve()
+	if len(path) == 1 {
+		return value, true
+	}
+	if value.Kind() != slog.KindGroup {
+		return slog.Value{}, false
+	}
+	for _, a := range value.Group() {
+		if v, ok := findAttr(a, path[1:]); ok {
+			return v, true
+		}
+	}
+	return slog.Value{}, false
+}
+
+const (
+	rateLimitEvictedKey = "(evicted)" // 被淘汰的键超出 maxKeys 时，在摘要中合并记录所使用的键
+	rateLimitReportKeys = 10          // 摘要中最多列出的键数量
+)
+
+type rateLimitBucket struct {
+	key     string
+	tokens  float64   // 剩余的令牌数量
+	last    time.Time // 上一次补充令牌的时间
+	dropped uint64    // 当前摘要间隔内被丢弃的日志记录数量
+}
+
+type rateLimitState struct {
+	rw             sync.Mutex
+	handler        slog.Handler             // 用于记录摘要的处理器
+	fields         []rateLimitField         // 组成键的字段
+	rate           float64                  // 每秒产生的令牌数量
+	burst          float64                  // 令牌桶容量
+	maxKeys        int                      // 最多保留的键数量
+	reportInterval time.Duration            // 摘要间隔
+	nextReport     time.Time                // 下一次记录摘要的时间
+	buckets        map[string]*list.Element // 令牌桶
+	lru            *list.List               // 键的使用顺序，最近使用的键位于头部
+	evicted        map[string]uint64        // 被淘汰但尚未记录摘要的键的丢弃数量
+	dropped        atomic.Uint64            // 被丢弃的日志记录数量
+}
+
+func (s *rateLimitState) allow(key string, now time.Time) bool {
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	s.rw.Lock()
+	var report map[string]uint64
+	if s.reportInterval > 0 && !now.Before(s.nextReport) {
+		report = s.collect()
+		s.nextReport = now.Add(s.reportInterval)
+	}
+
+	var bucket *rateLimitBucket
+	if element, exist := s.buckets[key]; exist {
+		s.lru.MoveToFront(element)
+		bucket = element.Value.(*rateLimitBucket)
+		if elapsed := now.Sub(bucket.last); elapsed > 0 {
+			bucket.tokens = min(s.burst, bucket.tokens+elapsed.Seconds()*s.rate)
+			bucket.last = now
+		}
+	} else {
+		bucket = &rateLimitBucket{key: key, tokens: s.burst, last: now}
+		s.buckets[key] = s.lru.PushFront(bucket)
+		if s.lru.Len() > s.maxKeys {
+			s.evict()
+		}
+	}
+
+	allowed := bucket.tokens >= 1
+	if allowed {
+		bucket.tokens--
+	} else {
+		bucket.dropped++
+	}
+	s.rw.Unlock()
+
+	s.report(report)
+	if !allowed {
+		s.dropped.Add(1)
+	}
+	return allowed
+}
+
+// evict 淘汰最久未使用的键，并保留其丢弃数量以便记录摘要
+//   - 未启用摘要时不保留丢弃数量
+//   - 保留的键数量同样不超过 maxKeys，超出部分将合并到 rateLimitEvictedKey 中
+func (s *rateLimitState) evict() {
+	element := s.lru.Back()
+	bucket := element.Value.(*rateLimitBucket)
+	s.lru.Remove(element)
+	delete(s.buckets, bucket.key)
+	if bucket.dropped == 0 || s.reportInterval <= 0 {
+		return
+	}
+	if s.evicted == nil {
+		s.evicted = make(map[string]uint64)
+	}
+	key := bucket.key
+	if _, exist := s.evicted[key]; !exist && len(s.evicted) >= s.maxKeys {
+		key = rateLimitEvictedKey
+	}
+	s.evicted[key] += bucket.dropped
+}
+
+// collect 收集并重置当前摘要间隔内每个键的丢弃数量
+func (s *rateLimitState) collect() map[string]uint64 {
+	report := s.evicted
+	s.evicted = nil
+	for _, element := range s.buckets {
+		bucket := element.Value.(*rateLimitBucket)
+		if bucket.dropped == 0 {
+			continue
+		}
+		if report == nil {
+			report = make(map[string]uint64)
+		}
+		report[bucket.key] += bucket.dropped
+		bucket.dropped = 0
+	}
+	return report
+}
+
+// report 将所有键的丢弃数量合并为一条摘要记录，摘要不属于任何调用者，因此使用中立的上下文
+//   - 摘要中仅列出丢弃数量最多的 rateLimitReportKeys 个键，以限制摘要的大小
+func (s *rateLimitState) report(report map[string]uint64) {
+	ctx := withoutCallerFrames(context.Background())
+	if len(report) == 0 || !s.handler.Enabled(ctx, LevelWarn) {
+		return
+	}
+
+	keys := make([]string, 0, len(report))
+	var total uint64
+	for key, dropped := range report {
+		keys = append(keys, key)
+		total += dropped
+	}
+	slices.SortFunc(keys, func(a, b string) int {
+		if c := cmp.Compare(report[b], report[a]); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	})
+	top := make([]any, 0, rateLimitReportKeys)
+	for _, key := range keys[:min(len(keys), rateLimitReportKeys)] {
+		top = append(top, slog.Uint64(key, report[key]))
+	}
+
+	summary := slog.NewRecord(time.Now(), LevelWarn, "log rate limit dropped records", 0)
+	summary.AddAttrs(
+		slog.Uint64("dropped", total),
+		slog.Int("keys", len(keys)),
+		slog.Group("top", top...),
+		slog.Duration("interval", s.reportInterval),
+	)
+	_ = s.handler.Handle(ctx, summary)
+}
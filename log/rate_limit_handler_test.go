@@ -0,0 +1,132 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newRateLimitTestHandler(t *testing.T, buf *bytes.Buffer, options *RateLimitOptions) RateLimitHandler {
+	t.Helper()
+	handler, err := NewRateLimitHandler(newHandler(GetConfigBuilder().Test().WithWriter(buf)), options)
+	if err != nil {
+		t.Fatalf("NewRateLimitHandler() error = %v", err)
+	}
+	return handler
+}
+
+// TestRateLimitHandlerRefill tests that the burst is spent at once and tokens are refilled up to the burst over time.
+func TestRateLimitHandlerRefill(t *testing.T) {
+	var buf bytes.Buffer
+	handler := newRateLimitTestHandler(t, &buf, NewRateLimitOptions().WithRate(1, 2).WithReportInterval(0))
+
+	start := time.Now()
+	allowed := func(at time.Duration, n int) int {
+		before := handler.Dropped()
+		for i := 0; i < n; i++ {
+			_ = handler.Handle(context.Background(), slog.NewRecord(start.Add(at), LevelInfo, "record", 0))
+		}
+		return n - int(handler.Dropped()-before)
+	}
+
+	if got := allowed(0, 3); got != 2 {
+		t.Fatalf("burst allowed %d records, want 2", got)
+	}
+	if got := allowed(time.Second, 3); got != 1 {
+		t.Fatalf("refill after 1s allowed %d records, want 1", got)
+	}
+	if got := allowed(time.Minute, 5); got != 2 {
+		t.Fatalf("refill after 1m allowed %d records, want the burst of 2", got)
+	}
+}
+
+// TestRateLimitHandlerEviction tests that the least recently used key is evicted once maxKeys is exceeded,
+// and that evicted keys are not retained when summaries are disabled.
+func TestRateLimitHandlerEviction(t *testing.T) {
+	var buf bytes.Buffer
+	handler := newRateLimitTestHandler(t, &buf, NewRateLimitOptions().WithKeyFields("attrs.tenant").WithRate(0, 1).WithMaxKeys(2).WithReportInterval(0))
+	logger := GetBuilder().FromHandler(handler)
+
+	for _, tenant := range []string{"a", "b", "a", "c", "b"} {
+		logger.Info("record", "tenant", tenant)
+	}
+	// b is evicted when c arrives, so it gets a fresh bucket while the second a is dropped
+	if handler.Dropped() != 1 {
+		t.Fatalf("Dropped() = %d, want 1", handler.Dropped())
+	}
+	for i := 0; i < 10; i++ {
+		logger.Info("record", "tenant", i)
+		logger.Info("record", "tenant", i)
+	}
+	if evicted := handler.(*rateLimitHandler).state.evicted; len(evicted) != 0 {
+		t.Fatalf("evicted keys are retained without summaries: %v", evicted)
+	}
+}
+
+// TestRateLimitHandlerKey tests that key expressions combine the group path with nested attrs.
+func TestRateLimitHandlerKey(t *testing.T) {
+	if _, err := NewRateLimitHandler(newSilentHandler(), NewRateLimitOptions().WithKeyFields("level", "attrs.")); err == nil {
+		t.Fatal("NewRateLimitHandler() with an invalid key error = nil, want error")
+	}
+
+	var buf bytes.Buffer
+	handler := newRateLimitTestHandler(t, &buf, NewRateLimitOptions().WithKeyFields("group", "attrs.http.status").WithRate(0, 1).WithReportInterval(0))
+	logger := GetBuilder().FromHandler(handler)
+
+	api, web := logger.WithGroup("api"), logger.WithGroup("web")
+	api.Info("record", Group("http", "status", 500))
+	api.Info("record", Group("http", "status", 500))
+	api.With(Group("http", "status", 404)).Info("record")
+	web.Info("record", Group("http", "status", 500))
+	if handler.Dropped() != 1 {
+		t.Fatalf("Dropped() = %d, want 1", handler.Dropped())
+	}
+}
+
+// TestRateLimitHandlerSummary tests that the drops of all keys are merged into one bounded summary,
+// recorded with the first record after the interval.
+func TestRateLimitHandlerSummary(t *testing.T) {
+	var buf bytes.Buffer
+	handler := newRateLimitTestHandler(t, &buf, NewRateLimitOptions().WithKeyFields("attrs.tenant").WithRate(0, 1).WithReportInterval(time.Minute))
+
+	start := time.Now()
+	for tenant := 0; tenant < rateLimitReportKeys+2; tenant++ {
+		for i := 0; i <= tenant+1; i++ {
+			record := slog.NewRecord(start, LevelInfo, "record", 0)
+			record.AddAttrs(slog.Int("tenant", tenant))
+			_ = handler.Handle(context.Background(), record)
+		}
+	}
+	if strings.Contains(buf.String(), "dropped") {
+		t.Fatalf("summary is recorded before the interval ends: %s", buf.String())
+	}
+	_ = handler.Handle(context.Background(), slog.NewRecord(start.Add(time.Minute), LevelInfo, "record", 0))
+
+	var summaries []string
+	plain := regexp.MustCompile("\x1b+\\[[0-9;]*m").ReplaceAllString(buf.String(), "")
+	for _, line := range strings.Split(plain, "\n") {
+		if strings.Contains(line, "dropped") {
+			summaries = append(summaries, line)
+		}
+	}
+	if len(summaries) != 1 {
+		t.Fatalf("got %d summaries, want 1: %s", len(summaries), buf.String())
+	}
+	summary := summaries[0]
+	// tenant n drops n+1 records, the two tenants with the fewest drops are not listed
+	for _, want := range []string{"dropped=78", "keys=12", "tenant=11=12", "tenant=2=3", "1m0s"} {
+		if !strings.Contains(summary, want) {
+			t.Fatalf("summary %q misses %q", summary, want)
+		}
+	}
+	if strings.Contains(summary, "tenant=0=") || strings.Contains(summary, "tenant=1=") {
+		t.Fatalf("summary %q lists more than %d keys", summary, rateLimitReportKeys)
+	}
+	if strings.Contains(summary, "rate_limit_handler_test.go") {
+		t.Fatalf("summary carries a caller: %s", summary)
+	}
+}
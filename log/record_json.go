@@ -0,0 +1,68 @@
+package log
+
+import (
+	"log/slog"
+	"time"
+)
+
+// recordToMap 将日志记录转换为便于 JSON 序列化的结构，group 及 attrs 为 Handler 通过 WithGroup 及 WithAttrs 累积的信息
+//   - 与文本格式一致，分组名作为独立的 "group" 字段，属性不会嵌套在分组中
+func recordToMap(group string, attrs []slog.Attr, record slog.Record) map[string]any {
+	m := make(map[string]any, 4+len(attrs)+record.NumAttrs())
+	m["time"] = record.Time.Format(time.RFC3339Nano)
+	m["level"] = record.Level.String()
+	m["msg"] = record.Message
+	if group != "" {
+		m["group"] = group
+	}
+	for _, attr := range attrs {
+		putJSONAttr(m, attr)
+	}
+	record.Attrs(func(attr slog.Attr) bool {
+		putJSONAttr(m, attr)
+		return true
+	})
+	return m
+}
+
+func putJSONAttr(m map[string]any, attr slog.Attr) {
+	attr.Value = attr.Value.Resolve()
+	if attr.Key == "" {
+		// 与 slog 的约定一致，空键的分组将被展开，其他空键属性将被忽略
+		if attr.Value.Kind() == slog.KindGroup {
+			for _, a := range attr.Value.Group() {
+				putJSONAttr(m, a)
+			}
+		}
+		return
+	}
+	m[attr.Key] = jsonAttrValue(attr.Value)
+}
+
+func jsonAttrValue(v slog.Value) any {
+	switch v.Kind() {
+	case slog.KindGroup:
+		m := make(map[string]any, len(v.Group()))
+		for _, a := range v.Group() {
+			putJSONAttr(m, a)
+		}
+		return m
+	case slog.KindTime:
+		return v.Time().Format(time.RFC3339Nano)
+	case slog.KindDuration:
+		return v.Duration().String()
+	case slog.KindAny:
+		switch x := v.Any().(type) {
+		case error:
+			return x.Error()
+		case stack:
+			return string(x)
+		case []byte:
+			return string(x)
+		default:
+			return x
+		}
+	default:
+		return v.Any()
+	}
+}
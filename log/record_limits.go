@@ -0,0 +1,179 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// RecordLimits 是日志记录的大小限制，它通过 LoggerConfiguration.WithRecordLimits 生效
+//   - 超出限制的内容将被截断，并以 "…(truncated 12345 bytes)" 形式的标记说明被截断的大小
+//   - 限制应当在被使用前设置完毕，同一个 RecordLimits 可以被多个配置共享，它们的截断次数将被累加
+type RecordLimits struct {
+	maxString     int           // 字符串及日志消息的最大字节数
+	maxAttrs      int           // 日志记录的最大属性数量，包含 WithAttrs 中的属性
+	maxGroupDepth int           // 分组的最大嵌套深度
+	maxAny        int           // 被序列化为 JSON 的值的最大字节数
+	maxRecord     int           // 格式化后的日志记录的最大字节数
+	truncated     atomic.Uint64 // 截断次数
+}
+
+// NewRecordLimits 创建一个默认的 RecordLimits
+//   - 默认不限制任何内容，值 <= 0 均表示不限制
+func NewRecordLimits() *RecordLimits {
+	return &RecordLimits{}
+}
+
+// WithMaxStringLength 设置字符串、[]byte 及日志消息的最大字节数
+func (l *RecordLimits) WithMaxStringLength(max int) *RecordLimits {
+	l.maxString = max
+	return l
+}
+
+// WithMaxAttrs 设置日志记录的最大属性数量，它包含 WithAttrs 中的属性，分组计为一个属性，超出的属性将被替换为一个截断标记
+func (l *RecordLimits) WithMaxAttrs(max int) *RecordLimits {
+	l.maxAttrs = max
+	return l
+}
+
+// WithMaxGroupDepth 设置属性分组的最大嵌套深度，超出深度的分组将被替换为一个截断标记
+func (l *RecordLimits) WithMaxGroupDepth(max int) *RecordLimits {
+	l.maxGroupDepth = max
+	return l
+}
+
+// WithMaxAnySize 设置被序列化为 JSON 的值的最大字节数，例如结构体、映射及切片
+func (l *RecordLimits) WithMaxAnySize(max int) *RecordLimits {
+	l.maxAny = max
+	return l
+}
+
+// WithMaxRecordSize 设置格式化后的日志记录的最大字节数，超出的部分将被截断，日志记录仍以换行符结尾
+func (l *RecordLimits) WithMaxRecordSize(max int) *RecordLimits {
+	l.maxRecord = max
+	return l
+}
+
+// Truncated 获取截断次数，每一个被截断的值、属性列表或日志记录均计为一次
+func (l *RecordLimits) Truncated() uint64 {
+	if l == nil {
+		return 0
+	}
+	return l.truncated.Load()
+}
+
+// truncateString 截断超出最大字节数的字符串
+func (l *RecordLimits) truncateString(s string) string {
+	if l == nil || l.maxString <= 0 || len(s) <= l.maxString {
+		return s
+	}
+	l.truncated.Add(1)
+	n := truncateIndex(s, l.maxString)
+	return s[:n] + truncatedMarker(len(s)-n, "bytes")
+}
+
+// truncateAny 截断超出最大字节数的 JSON 序列化结果
+func (l *RecordLimits) truncateAny(data []byte) []byte {
+	if l == nil || l.maxAny <= 0 || len(data) <= l.maxAny {
+		return data
+	}
+	l.truncated.Add(1)
+	n := truncateIndex(string(data), l.maxAny)
+	return append(data[:n:n], truncatedMarker(len(data)-n, "bytes")...)
+}
+
+// truncateRecord 截断超出最大字节数的格式化后的日志记录，reset 将被写入到截断标记之前，用于重置被截断的颜色
+func (l *RecordLimits) truncateRecord(data []byte, reset string) []byte {
+	if l == nil || l.maxRecord <= 0 || len(data) <= l.maxRecord {
+		return data
+	}
+	l.truncated.Add(1)
+	// 以最大的截断字节数预留截断标记的空间，使截断后的日志记录不超过最大字节数
+	reserved := 1 + len(reset) + len(truncatedMarker(len(data), "bytes"))
+	n := truncateIndex(string(data), l.maxRecord-reserved)
+	if reset != "" {
+		// 避免截断颜色的转义序列
+		if i := bytes.LastIndexByte(data[:n], 0x1b); i >= 0 && bytes.IndexByte(data[i:n], 'm') < 0 {
+			n = i
+		}
+	}
+	marker := truncatedMarker(len(data)-1-n, "bytes")
+	truncated := make([]byte, 0, n+len(reset)+len(marker)+1)
+	truncated = append(truncated, data[:n]...)
+	truncated = append(truncated, reset...)
+	truncated = append(truncated, marker...)
+	return append(truncated, '\n')
+}
+
+// exceedsAttrs 检查属性数量是否超出限制
+func (l *RecordLimits) exceedsAttrs(n int) bool {
+	return l != nil && l.maxAttrs > 0 && n > l.maxAttrs
+}
+
+// limitAttrs 获取不超出最大属性数量的属性，超出的属性将被替换为一个截断标记
+func (l *RecordLimits) limitAttrs(fixed []slog.Attr, record slog.Record) []slog.Attr {
+	l.truncated.Add(1)
+	attrs := make([]slog.Attr, 0, l.maxAttrs+1)
+	attrs = append(attrs, fixed[:min(len(fixed), l.maxAttrs)]...)
+	record.Attrs(func(attr slog.Attr) bool {
+		if len(attrs) == l.maxAttrs {
+			return false
+		}
+		attrs = append(attrs, attr)
+		return true
+	})
+	omitted := len(fixed) + record.NumAttrs() - l.maxAttrs
+	return append(attrs, slog.String("…", fmt.Sprintf("(truncated %d attrs)", omitted)))
+}
+
+// limitAttr 截断属性中超出限制的字符串及分组，slog.LogValuer 将在截断前被解析
+func (l *RecordLimits) limitAttr(attr slog.Attr) slog.Attr {
+	if l == nil || (l.maxString <= 0 && l.maxGroupDepth <= 0) {
+		return attr
+	}
+	return l.limitAttrDepth(attr, 0)
+}
+
+func (l *RecordLimits) limitAttrDepth(attr slog.Attr, depth int) slog.Attr {
+	// slog.LogValuer 需要先被解析，否则其生成的值将绕过限制
+	attr.Value = attr.Value.Resolve()
+	switch attr.Value.Kind() {
+	case slog.KindString:
+		if s := attr.Value.String(); len(s) > l.maxString && l.maxString > 0 {
+			attr.Value = slog.StringValue(l.truncateString(s))
+		}
+	case slog.KindGroup:
+		group := attr.Value.Group()
+		if l.maxGroupDepth > 0 && depth >= l.maxGroupDepth {
+			l.truncated.Add(1)
+			attr.Value = slog.StringValue(truncatedMarker(len(group), "attrs"))
+			return attr
+		}
+		limited := make([]slog.Attr, len(group))
+		for i, a := range group {
+			limited[i] = l.limitAttrDepth(a, depth+1)
+		}
+		attr.Value = slog.GroupValue(limited...)
+	case slog.KindAny:
+		if b, ok := attr.Value.Any().([]byte); ok && l.maxString > 0 && len(b) > l.maxString {
+			attr.Value = slog.StringValue(l.truncateString(string(b)))
+		}
+	}
+	return attr
+}
+
+// truncateIndex 获取不超过 limit 字节且不会截断 UTF-8 字符的截断位置
+func truncateIndex(s string, limit int) int {
+	n := max(min(limit, len(s)), 0)
+	for n > 0 && n < len(s) && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return n
+}
+
+func truncatedMarker(n int, unit string) string {
+	return "…(truncated " + strconv.Itoa(n) + " " + unit + ")"
+}
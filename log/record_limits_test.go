@@ -0,0 +1,100 @@
+package log
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// limitsTestValuer is a slog.LogValuer that resolves to a long string.
+type limitsTestValuer struct{}
+
+func (limitsTestValuer) LogValue() slog.Value {
+	return slog.StringValue(strings.Repeat("v", 20))
+}
+
+// TestRecordLimits tests that oversized strings, attr lists, nested groups, serialized values, resolved values and records
+// are truncated with markers.
+func TestRecordLimits(t *testing.T) {
+	cases := []struct {
+		name      string
+		limits    func() *RecordLimits
+		log       func(logger Logger)
+		want      []string
+		unwanted  string
+		truncated uint64
+		maxSize   int
+	}{
+		{
+			name: "attrs",
+			limits: func() *RecordLimits {
+				return NewRecordLimits().WithMaxStringLength(8).WithMaxAttrs(4).WithMaxGroupDepth(1).WithMaxAnySize(16)
+			},
+			log: func(logger Logger) {
+				logger.Info("limits",
+					"text", strings.Repeat("a", 20),
+					"bytes", []byte(strings.Repeat("b", 20)),
+					"payload", map[string]string{"key": strings.Repeat("c", 20)},
+					Group("outer", Group("inner", "key", "value")),
+					"extra", 1,
+					"more", 2,
+				)
+			},
+			want: []string{
+				`"aaaaaaaa…(truncated 12 bytes)"`,
+				`"bbbbbbbb…(truncated 12 bytes)"`,
+				`{"key":"cccccccc…(truncated 14 bytes)`,
+				`"…(truncated 1 attrs)"`,
+				`(truncated 2 attrs)`,
+			},
+			unwanted:  "extra",
+			truncated: 5,
+		},
+		{
+			name:   "log valuer",
+			limits: func() *RecordLimits { return NewRecordLimits().WithMaxStringLength(8) },
+			log: func(logger Logger) {
+				logger.Info("valuer", "value", limitsTestValuer{}, Group("group", "nested", limitsTestValuer{}))
+			},
+			want:      []string{`"vvvvvvvv…(truncated 12 bytes)"`},
+			unwanted:  strings.Repeat("v", 9),
+			truncated: 2,
+		},
+		{
+			name:   "record size",
+			limits: func() *RecordLimits { return NewRecordLimits().WithMaxRecordSize(64) },
+			log: func(logger Logger) {
+				logger.Info(strings.Repeat("x", 4), "a", strings.Repeat("y", 6), "b", strings.Repeat("z", 6), "c", 1, "d", 2)
+			},
+			want:      []string{" bytes)\n"},
+			truncated: 1,
+			maxSize:   64,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			limits := c.limits()
+			logger := GetBuilder().FromConfiguration(GetConfigBuilder().Test().WithWriter(&buf).WithEnableColor(false).WithRecordLimits(limits))
+			c.log(logger)
+
+			out := buf.String()
+			for _, want := range c.want {
+				if !strings.Contains(out, want) {
+					t.Fatalf("output does not contain %q: %s", want, out)
+				}
+			}
+			if c.unwanted != "" && strings.Contains(out, c.unwanted) {
+				t.Fatalf("output contains %q: %s", c.unwanted, out)
+			}
+			if c.maxSize > 0 && len(out) > c.maxSize {
+				t.Fatalf("record is not truncated to %d bytes (%d): %q", c.maxSize, len(out), out)
+			}
+			if limits.Truncated() != c.truncated {
+				t.Fatalf("Truncated() = %d, want %d: %s", limits.Truncated(), c.truncated, out)
+			}
+		})
+	}
+}
@@ -0,0 +1,439 @@
+package log
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding"
+	"encoding/hex"
+	jsonIter "github.com/json-iterator/go"
+	"log/slog"
+	"path"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+var (
+	_ RedactHandler = (*redactHandler)(nil)
+
+	// RedactCardNumber 匹配由 13 至 19 位数字组成的银行卡号，数字之间允许存在空格或 "-"
+	//   - 通过 WithValue 使用时，仅通过 Luhn 校验的内容会被替换，以免订单号等普通的长数字被误判
+	RedactCardNumber = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+
+	// RedactJWT 匹配 JSON Web Token
+	RedactJWT = regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+
+	// RedactEmail 匹配电子邮件地址
+	RedactEmail = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+)
+
+// RedactStyle 是脱敏的替换方式
+type RedactStyle uint8
+
+const (
+	RedactFull    RedactStyle = iota // 使用 "[REDACTED]" 替换全部内容
+	RedactPartial                    // 仅保留末尾 4 个字符，其余字符使用 "*" 替换，当内容不超过 4 个字符时全部替换
+	RedactHash                       // 使用 "sha256:" 及 16 位十六进制摘要替换，相同的内容将得到相同的摘要，便于关联
+)
+
+// RedactOptions 是 RedactHandler 的选项
+type RedactOptions struct {
+	keys    []redactKeyRule   // 键规则
+	values  []redactValueRule // 值规则
+	hashKey []byte            // RedactHash 所使用的 HMAC 密钥
+}
+
+type redactKeyRule struct {
+	pattern string
+	fold    bool // 是否忽略大小写
+	style   RedactStyle
+}
+
+type redactValueRule struct {
+	re    *regexp.Regexp
+	style RedactStyle
+	valid func(match string) bool // 对匹配内容的额外校验，为 nil 时不进行校验
+}
+
+// NewRedactOptions 创建一个空的 RedactOptions，可以通过 WithDefaults 添加常用的规则
+func NewRedactOptions() *RedactOptions {
+	return &RedactOptions{}
+}
+
+// WithDefaults 添加常用的规则
+//   - 忽略大小写的键：password、passwd、secret、token、*_token、api_key、apikey、authorization、cookie 及 set-cookie，使用 RedactFull 替换
+//   - 值：RedactCardNumber 及 RedactEmail 使用 RedactPartial 替换，RedactJWT 使用 RedactFull 替换
+func (o *RedactOptions) WithDefaults() *RedactOptions {
+	for _, key := range []string{"password", "passwd", "secret", "token", "*_token", "api_key", "apikey", "authorization", "cookie", "set-cookie"} {
+		o.WithKeyFold(key, RedactFull)
+	}
+	return o.
+		WithValue(RedactCardNumber, RedactPartial).
+		WithValue(RedactJWT, RedactFull).
+		WithValue(RedactEmail, RedactPartial)
+}
+
+// WithKey 添加一个区分大小写的键规则，匹配的属性值将被整体替换
+//   - pattern 可以是精确的键或 path.Match 的通配符语法，例如 "*_secret"
+//   - pattern 将分别与属性键及以 "." 连接的完整路径进行匹配，例如 "user.password"，完整路径包含 WithGroup 构建的分组
+func (o *RedactOptions) WithKey(pattern string, style RedactStyle) *RedactOptions {
+	o.keys = append(o.keys, redactKeyRule{pattern: pattern, style: style})
+	return o
+}
+
+// WithKeyFold 添加一个忽略大小写的键规则，其余与 WithKey 相同
+func (o *RedactOptions) WithKeyFold(pattern string, style RedactStyle) *RedactOptions {
+	o.keys = append(o.keys, redactKeyRule{pattern: strings.ToLower(pattern), fold: true, style: style})
+	return o
+}
+
+// WithValue 添加一个值规则，字符串、错误信息、日志消息以及结构体中的字符串内匹配 re 的部分将被替换
+func (o *RedactOptions) WithValue(re *regexp.Regexp, style RedactStyle) *RedactOptions {
+	rule := redactValueRule{re: re, style: style}
+	if re == RedactCardNumber {
+		rule.valid = luhnValid
+	}
+	o.values = append(o.values, rule)
+	return o
+}
+
+// WithHashKey 设置 RedactHash 所使用的 HMAC 密钥，未设置时将使用 SHA-256，建议设置密钥以避免通过穷举还原低熵的内容
+func (o *RedactOptions) WithHashKey(key []byte) *RedactOptions {
+	o.hashKey = key
+	return o
+}
+
+// RedactHandler 是一个在日志记录到达被包装的处理器前对敏感内容进行脱敏的日志处理器
+//   - 脱敏作用于日志消息、日志记录及 WithAttrs 中的属性，包括嵌套的分组以及将被序列化为 JSON 的结构体、映射及切片
+//   - 结构体等值将先被序列化为 JSON 再进行脱敏，脱敏后以 JSON 对象的形式交由被包装的处理器
+//   - 配置中 ContextExtractor 提取的属性及 WithEnrichment 的静态元数据由最终的处理器在脱敏之后添加，因此不会被脱敏
+//     当提取的属性可能包含敏感内容时，应当通过 LoggerConfiguration.WithPreHandleHook 注册 NewRedactHook，使脱敏在提取之后进行
+//
+// 当需要对 Multi 构建的 Logger 进行脱敏时，应当包装其 Handler，以保证所有处理器均只能接收到脱敏后的日志记录，例如：
+//
+//	logger := GetBuilder().FromHandler(NewRedactHandler(GetBuilder().Multi(a, b).Handler(), NewRedactOptions().WithDefaults()))
+type RedactHandler interface {
+	Handler
+
+	// Redacted 获取被脱敏的内容数量
+	Redacted() uint64
+}
+
+// NewRedactHandler 创建一个包装 handler 的脱敏日志处理器，当 options 为 nil 时将使用包含 WithDefaults 规则的选项
+func NewRedactHandler(handler Handler, options *RedactOptions) RedactHandler {
+	if options == nil {
+		options = NewRedactOptions().WithDefaults()
+	}
+	return &redactHandler{
+		redactor: &redactor{
+			keys:    append([]redactKeyRule(nil), options.keys...),
+			values:  append([]redactValueRule(nil), options.values...),
+			hashKey: options.hashKey,
+		},
+		handler: handler,
+	}
+}
+
+type redactHandler struct {
+	redactor *redactor    // 脱敏器，它在所有派生的处理器间共享
+	handler  slog.Handler // 被包装的处理器
+	group    string       // 通过 WithGroup 构建的分组路径
+}
+
+func (h *redactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *redactHandler) Handle(ctx context.Context, record slog.Record) error {
+	ctx = withCallerFrames(ctx)
+	ctx, record = resolveContextAttrs(ctx, record)
+	return h.handler.Handle(ctx, h.redactor.record(h.group, record))
+}
+
+func (h *redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		redacted[i] = h.redactor.attr(h.group, attr)
+	}
+	return &redactHandler{redactor: h.redactor, handler: h.handler.WithAttrs(redacted), group: h.group}
+}
+
+func (h *redactHandler) WithGroup(name string) slog.Handler {
+	n := &redactHandler{redactor: h.redactor, handler: h.handler.WithGroup(name), group: name}
+	if h.group != "" {
+		n.group = h.group + "." + name
+	}
+	return n
+}
+
+func (h *redactHandler) Redacted() uint64 {
+	return h.redactor.redacted.Load()
+}
+
+// NewRedactHook 创建一个对日志记录进行脱敏的前置钩子，当 options 为 nil 时将使用包含 WithDefaults 规则的选项
+//   - 前置钩子在最终的处理器提取 ContextExtractor 的属性之后执行，因此这些属性同样会被脱敏
+//   - 钩子仅作用于日志消息及日志记录中的属性，WithAttrs 的属性仍需通过 RedactHandler 进行脱敏
+func NewRedactHook(options *RedactOptions) PreHandleHook {
+	r := NewRedactHandler(nil, options).(*redactHandler).redactor
+	return func(ctx context.Context, group string, record *slog.Record) bool {
+		*record = r.record(group, *record)
+		return true
+	}
+}
+
+type redactor struct {
+	keys     []redactKeyRule
+	values   []redactValueRule
+	hashKey  []byte
+	redacted atomic.Uint64 // 被脱敏的内容数量
+}
+
+// record 对日志记录的消息及属性进行脱敏，返回新的日志记录
+func (r *redactor) record(group string, record slog.Record) slog.Record {
+	msg, _ := r.string(record.Message)
+	redacted := slog.NewRecord(record.Time, record.Level, msg, record.PC)
+	record.Attrs(func(attr slog.Attr) bool {
+		redacted.AddAttrs(r.attr(group, attr))
+		return true
+	})
+	return redacted
+}
+
+// matchKey 判断属性键或完整路径是否匹配键规则
+func (r *redactor) matchKey(key, fullPath string) (RedactStyle, bool) {
+	for _, rule := range r.keys {
+		k, p := key, fullPath
+		if rule.fold {
+			k, p = strings.ToLower(k), strings.ToLower(p)
+		}
+		if matched, _ := path.Match(rule.pattern, k); matched {
+			return rule.style, true
+		}
+		if matched, _ := path.Match(rule.pattern, p); matched {
+			return rule.style, true
+		}
+	}
+	return 0, false
+}
+
+func (r *redactor) attr(prefix string, attr slog.Attr) slog.Attr {
+	fullPath := attr.Key
+	if prefix != "" {
+		fullPath = prefix + "." + attr.Key
+	}
+	if attr.Key == "" {
+		fullPath = prefix
+	}
+
+	value := attr.Value.Resolve()
+	if attr.Key != "" {
+		if style, matched := r.matchKey(attr.Key, fullPath); matched {
+			return slog.String(attr.Key, r.mask(style, r.text(value)))
+		}
+	}
+
+	switch value.Kind() {
+	case slog.KindGroup:
+		group := value.Group()
+		redacted := make([]slog.Attr, len(group))
+		for i, a := range group {
+			redacted[i] = r.attr(fullPath, a)
+		}
+		return slog.Attr{Key: attr.Key, Value: slog.GroupValue(redacted...)}
+	case slog.KindString:
+		if s, changed := r.string(value.String()); changed {
+			return slog.String(attr.Key, s)
+		}
+	case slog.KindAny:
+		if v, changed := r.any(fullPath, value.Any()); changed {
+			return slog.Any(attr.Key, v)
+		}
+	}
+	return slog.Attr{Key: attr.Key, Value: value}
+}
+
+// any 对任意类型的值进行脱敏，结构体、映射及切片将被序列化为 JSON 后进行脱敏
+func (r *redactor) any(fullPath string, v any) (any, bool) {
+	switch value := v.(type) {
+	case nil:
+		return nil, false
+	case stackError, stackErrorTracks, stack:
+		return v, false
+	case error:
+		if s, changed := r.string(value.Error()); changed {
+			return &redactedError{msg: s, err: value}, true
+		}
+		return v, false
+	case encoding.TextMarshaler:
+		if data, err := value.MarshalText(); err == nil {
+			if s, changed := r.string(string(data)); changed {
+				return s, true
+			}
+		}
+		return v, false
+	case []byte:
+		if s, changed := r.string(string(value)); changed {
+			return s, true
+		}
+		return v, false
+	}
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return v, false
+		}
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+	default:
+		return v, false
+	}
+
+	data, err := jsonIter.ConfigCompatibleWithStandardLibrary.Marshal(v)
+	if err != nil {
+		return v, false
+	}
+	var decoded any
+	if err = jsonIter.ConfigCompatibleWithStandardLibrary.Unmarshal(data, &decoded); err != nil {
+		return v, false
+	}
+	if redacted, changed := r.json(fullPath, decoded); changed {
+		return redacted, true
+	}
+	return v, false
+}
+
+// json 对 JSON 反序列化后的值进行脱敏
+func (r *redactor) json(fullPath string, v any) (any, bool) {
+	switch value := v.(type) {
+	case map[string]any:
+		changed := false
+		for key, item := range value {
+			itemPath := key
+			if fullPath != "" {
+				itemPath = fullPath + "." + key
+			}
+			if style, matched := r.matchKey(key, itemPath); matched {
+				value[key] = r.mask(style, r.text(slog.AnyValue(item)))
+				changed = true
+				continue
+			}
+			if redacted, itemChanged := r.json(itemPath, item); itemChanged {
+				value[key] = redacted
+				changed = true
+			}
+		}
+		return value, changed
+	case []any:
+		changed := false
+		for i, item := range value {
+			if redacted, itemChanged := r.json(fullPath, item); itemChanged {
+				value[i] = redacted
+				changed = true
+			}
+		}
+		return value, changed
+	case string:
+		return r.string(value)
+	default:
+		return v, false
+	}
+}
+
+// string 使用值规则对字符串进行脱敏
+func (r *redactor) string(s string) (string, bool) {
+	changed := false
+	for _, rule := range r.values {
+		s = rule.re.ReplaceAllStringFunc(s, func(match string) string {
+			if rule.valid != nil && !rule.valid(match) {
+				return match
+			}
+			changed = true
+			return r.mask(rule.style, match)
+		})
+	}
+	return s, changed
+}
+
+// text 获取值的文本形式，它被用于计算替换内容
+func (r *redactor) text(value slog.Value) string {
+	if value.Kind() == slog.KindAny {
+		switch v := value.Any().(type) {
+		case string:
+			return v
+		case error:
+			return v.Error()
+		case nil:
+			return ""
+		}
+		if data, err := jsonIter.ConfigCompatibleWithStandardLibrary.Marshal(value.Any()); err == nil {
+			return string(data)
+		}
+	}
+	return value.String()
+}
+
+func (r *redactor) mask(style RedactStyle, s string) string {
+	r.redacted.Add(1)
+	switch style {
+	case RedactPartial:
+		n := utf8.RuneCountInString(s)
+		if n <= 4 {
+			return strings.Repeat("*", n)
+		}
+		runes := []rune(s)
+		return strings.Repeat("*", n-4) + string(runes[n-4:])
+	case RedactHash:
+		var sum []byte
+		if len(r.hashKey) > 0 {
+			mac := hmac.New(sha256.New, r.hashKey)
+			mac.Write([]byte(s))
+			sum = mac.Sum(nil)
+		} else {
+			digest := sha256.Sum256([]byte(s))
+			sum = digest[:]
+		}
+		return "sha256:" + hex.EncodeToString(sum[:8])
+	default:
+		return "[REDACTED]"
+	}
+}
+
+// luhnValid 判断由数字、空格及 "-" 组成的内容是否通过 Luhn 校验
+func luhnValid(s string) bool {
+	sum, n := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n > 0 && sum%10 == 0
+}
+
+// redactedError 是信息被脱敏的错误，它保留了原始错误以便 errors.Is 及 errors.As 使用
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string {
+	return e.msg
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,100 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestRedactHandler tests that sensitive keys and values are redacted in nested groups and structs
+// before any handler of a multi logger sees the record.
+func TestRedactHandler(t *testing.T) {
+	type credentials struct {
+		User     string `json:"user"`
+		Password string `json:"password"`
+		Email    string `json:"email"`
+	}
+
+	var a, b bytes.Buffer
+	builder := GetBuilder()
+	multi := builder.Multi(
+		builder.FromConfiguration(GetConfigBuilder().Test().WithWriter(&a)),
+		builder.FromConfiguration(GetConfigBuilder().Test().WithWriter(&b)),
+	)
+	options := NewRedactOptions().WithDefaults().WithKey("user.ssn", RedactHash)
+	redact := NewRedactHandler(multi.Handler(), options)
+	logger := builder.FromHandler(redact)
+
+	logger.With("Authorization", "Bearer abc").Info("login",
+		"credentials", credentials{User: "alice", Password: "hunter2", Email: "alice@example.com"},
+		Group("user", "ssn", "123-45-6789"),
+		"card", "4111 1111 1111 1111",
+		"order_id", "1234567890123456",
+		"err", errors.New("token eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiIxIn0.sig rejected"),
+	)
+
+	for _, out := range []string{a.String(), b.String()} {
+		for _, leaked := range []string{"hunter2", "alice@example.com", "123-45-6789", "4111 1111 1111 1111", "Bearer abc", "eyJhbGci"} {
+			if strings.Contains(out, leaked) {
+				t.Fatalf("output leaks %q: %s", leaked, out)
+			}
+		}
+		for _, kept := range []string{"alice", "1111", "sha256:", "rejected", "1234567890123456"} {
+			if !strings.Contains(out, kept) {
+				t.Fatalf("output misses %q: %s", kept, out)
+			}
+		}
+	}
+	if redact.Redacted() != 6 {
+		t.Fatalf("Redacted() = %d, want 6", redact.Redacted())
+	}
+}
+
+// TestRedactHandlerContextAttrs tests that attrs stashed in the context are redacted and logged only once.
+func TestRedactHandlerContextAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	redact := NewRedactHandler(newHandler(GetConfigBuilder().Test().WithWriter(&buf)), NewRedactOptions().WithDefaults())
+	logger := GetBuilder().FromHandler(redact)
+
+	ctx := ContextWithAttrs(context.Background(), String("password", "hunter2"), String("request_id", "r-1"))
+	logger.InfoContext(ctx, "login")
+
+	out := buf.String()
+	if strings.Contains(out, "hunter2") {
+		t.Fatalf("context attr is not redacted: %s", out)
+	}
+	if strings.Count(out, "password") != 1 || strings.Count(out, "r-1") != 1 {
+		t.Fatalf("context attrs are missing or duplicated: %s", out)
+	}
+}
+
+// TestRedactHook tests that attrs added by a context extractor bypass the handler but are redacted by the hook.
+func TestRedactHook(t *testing.T) {
+	extractor := func(ctx context.Context) []Attr {
+		return []Attr{String("token", "s3cr3t")}
+	}
+	for _, c := range []struct {
+		name   string
+		hook   bool
+		leaked bool
+	}{
+		{name: "handler", hook: false, leaked: true},
+		{name: "hook", hook: true, leaked: false},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			config := GetConfigBuilder().Test().WithWriter(&buf).WithContextExtractor(extractor)
+			if c.hook {
+				config.WithPreHandleHook(NewRedactHook(nil))
+			}
+			logger := GetBuilder().FromHandler(NewRedactHandler(newHandler(config), nil))
+			logger.InfoContext(context.Background(), "request")
+
+			if leaked := strings.Contains(buf.String(), "s3cr3t"); leaked != c.leaked {
+				t.Fatalf("extracted token leaked = %v, want %v: %s", leaked, c.leaked, buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package log
+
+import (
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+var _ ReopenFileWriter = (*reopenFileWriter)(nil)
+
+// ReopenFileOptions 是 ReopenFileWriter 的选项
+type ReopenFileOptions struct {
+	perm         os.FileMode     // 创建文件时使用的权限
+	signals      []os.Signal     // 触发重新打开文件的信号
+	errorHandler func(err error) // 通过信号重新打开文件失败时的错误处理函数
+}
+
+// NewReopenFileOptions 创建一个默认的 ReopenFileOptions
+//   - 默认以 0644 权限创建文件，并在接收到 SIGHUP 信号时重新打开文件
+func NewReopenFileOptions() *ReopenFileOptions {
+	return &ReopenFileOptions{
+		perm:    0644,
+		signals: []os.Signal{syscall.SIGHUP},
+	}
+}
+
+// WithPerm 设置创建文件时使用的权限
+func (o *ReopenFileOptions) WithPerm(perm os.FileMode) *ReopenFileOptions {
+	o.perm = perm
+	return o
+}
+
+// WithSignals 设置触发重新打开文件的信号，当未指定任何信号时将不会监听信号
+func (o *ReopenFileOptions) WithSignals(signals ...os.Signal) *ReopenFileOptions {
+	o.signals = signals
+	return o
+}
+
+// WithErrorHandler 设置通过信号重新打开文件失败时的错误处理函数，失败时将继续使用原有的文件
+func (o *ReopenFileOptions) WithErrorHandler(handler func(err error)) *ReopenFileOptions {
+	o.errorHandler = handler
+	return o
+}
+
+// ReopenFileWriter 是一个支持重新打开的文件日志写入器，它适用于配合外部的 logrotate 使用
+//   - 文件以 O_APPEND 模式打开，多个进程可以安全的共享同一个文件
+//   - 重新打开的过程中，并发的写入不会丢失或被截断，它们会被写入到旧文件或新文件中的其中之一
+type ReopenFileWriter interface {
+	io.WriteCloser
+
+	// Reopen 重新打开文件，当打开新文件失败时将继续使用原有的文件
+	Reopen() error
+
+	// Path 获取文件路径
+	Path() string
+}
+
+// NewReopenFileWriter 创建一个支持重新打开的文件日志写入器，当 options 为 nil 时将使用默认选项
+func NewReopenFileWriter(path string, options *ReopenFileOptions) (ReopenFileWriter, error) {
+	if options == nil {
+		options = NewReopenFileOptions()
+	}
+	w := &reopenFileWriter{
+		path: path,
+		perm: options.perm,
+	}
+	f, err := w.open()
+	if err != nil {
+		return nil, err
+	}
+	w.file = f
+
+	if len(options.signals) > 0 {
+		w.signals = make(chan os.Signal, 1)
+		w.done = make(chan struct{})
+		signal.Notify(w.signals, options.signals...)
+		go w.watch(options.errorHandler)
+	}
+	return w, nil
+}
+
+type reopenFileWriter struct {
+	rw        sync.RWMutex
+	path      string         // 文件路径
+	perm      os.FileMode    // 创建文件时使用的权限
+	file      *os.File       // 当前正在写入的文件
+	signals   chan os.Signal // 信号通道
+	done      chan struct{}  // 关闭通道
+	closeOnce sync.Once
+}
+
+func (w *reopenFileWriter) open() (*os.File, error) {
+	return os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, w.perm)
+}
+
+func (w *reopenFileWriter) watch(errorHandler func(err error)) {
+	for {
+		select {
+		case <-w.signals:
+			if err := w.Reopen(); err != nil && errorHandler != nil {
+				errorHandler(err)
+			}
+		case <-w.done:
+			return
+		}
+	}
+}
+
+func (w *reopenFileWriter) Write(p []byte) (n int, err error) {
+	// 写入时持有读锁，以便并发写入的同时阻止在写入过程中替换文件
+	w.rw.RLock()
+	defer w.rw.RUnlock()
+	if w.file == nil {
+		return 0, os.ErrClosed
+	}
+	return w.file.Write(p)
+}
+
+func (w *reopenFileWriter) Reopen() error {
+	// 在替换前打开新文件，避免打开失败时丢失写入目标
+	f, err := w.open()
+	if err != nil {
+		return err
+	}
+
+	w.rw.Lock()
+	if w.file == nil {
+		w.rw.Unlock()
+		_ = f.Close()
+		return os.ErrClosed
+	}
+	old := w.file
+	w.file = f
+	w.rw.Unlock()
+
+	return old.Close()
+}
+
+func (w *reopenFileWriter) Path() string {
+	return w.path
+}
+
+func (w *reopenFileWriter) Close() (err error) {
+	w.closeOnce.Do(func() {
+		if w.signals != nil {
+			signal.Stop(w.signals)
+			close(w.done)
+		}
+
+		w.rw.Lock()
+		defer w.rw.Unlock()
+		err = w.file.Close()
+		w.file = nil
+	})
+	return err
+}
@@ -0,0 +1,143 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func newReopenFileTestWriter(t *testing.T) (ReopenFileWriter, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "app.log")
+	writer, err := NewReopenFileWriter(path, NewReopenFileOptions().WithSignals())
+	if err != nil {
+		t.Fatalf("NewReopenFileWriter() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = writer.Close()
+	})
+	return writer, path
+}
+
+func readReopenFileTestLines(t *testing.T, paths ...string) []string {
+	t.Helper()
+	var lines []string
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("ReadFile(%s) error = %v", path, err)
+		}
+		if len(data) == 0 {
+			continue
+		}
+		lines = append(lines, strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")...)
+	}
+	return lines
+}
+
+// TestReopenFileWriter tests that writes keep going to the renamed file until Reopen switches to a new file at the path.
+func TestReopenFileWriter(t *testing.T) {
+	writer, path := newReopenFileTestWriter(t)
+	rotated := path + ".1"
+
+	_, _ = writer.Write([]byte("before\n"))
+	if err := os.Rename(path, rotated); err != nil {
+		t.Fatalf("Rename() error = %v", err)
+	}
+	_, _ = writer.Write([]byte("renamed\n"))
+	if err := writer.Reopen(); err != nil {
+		t.Fatalf("Reopen() error = %v", err)
+	}
+	_, _ = writer.Write([]byte("after\n"))
+
+	if lines := readReopenFileTestLines(t, rotated); strings.Join(lines, ",") != "before,renamed" {
+		t.Fatalf("rotated file = %q, want before and renamed", lines)
+	}
+	if lines := readReopenFileTestLines(t, path); strings.Join(lines, ",") != "after" {
+		t.Fatalf("new file = %q, want after", lines)
+	}
+}
+
+// TestReopenFileWriterConcurrent tests that no write is lost or torn while the file is rotated and reopened concurrently.
+func TestReopenFileWriterConcurrent(t *testing.T) {
+	const writers, writes, rotations = 8, 200, 5
+	writer, path := newReopenFileTestWriter(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < writes; j++ {
+				if _, err := fmt.Fprintf(writer, "writer=%d seq=%d\n", i, j); err != nil {
+					t.Errorf("Write() error = %v", err)
+					return
+				}
+			}
+		}(i)
+	}
+
+	paths := []string{path}
+	for i := 1; i <= rotations; i++ {
+		rotated := fmt.Sprintf("%s.%d", path, i)
+		if err := os.Rename(path, rotated); err != nil {
+			t.Fatalf("Rename() error = %v", err)
+		}
+		if err := writer.Reopen(); err != nil {
+			t.Fatalf("Reopen() error = %v", err)
+		}
+		paths = append(paths, rotated)
+	}
+	wg.Wait()
+
+	lines := readReopenFileTestLines(t, paths...)
+	if len(lines) != writers*writes {
+		t.Fatalf("got %d lines, want %d", len(lines), writers*writes)
+	}
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "writer=") || !strings.Contains(line, " seq=") {
+			t.Fatalf("torn line %q", line)
+		}
+	}
+}
+
+// TestReopenFileWriterFailedReopen tests that a failed reopen keeps writing to the old file.
+func TestReopenFileWriterFailedReopen(t *testing.T) {
+	writer, path := newReopenFileTestWriter(t)
+	rotated := path + ".1"
+
+	if err := os.Rename(path, rotated); err != nil {
+		t.Fatalf("Rename() error = %v", err)
+	}
+	// a directory at the path makes opening the new file fail
+	if err := os.Mkdir(path, 0755); err != nil {
+		t.Fatalf("Mkdir() error = %v", err)
+	}
+	if err := writer.Reopen(); err == nil {
+		t.Fatal("Reopen() error = nil, want error")
+	}
+	if _, err := writer.Write([]byte("kept\n")); err != nil {
+		t.Fatalf("Write() after a failed Reopen() error = %v", err)
+	}
+	if lines := readReopenFileTestLines(t, rotated); strings.Join(lines, ",") != "kept" {
+		t.Fatalf("old file = %q, want kept", lines)
+	}
+}
+
+// TestReopenFileWriterClosed tests that Write and Reopen report os.ErrClosed after Close.
+func TestReopenFileWriterClosed(t *testing.T) {
+	writer, _ := newReopenFileTestWriter(t)
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if _, err := writer.Write([]byte("closed\n")); !errors.Is(err, os.ErrClosed) {
+		t.Fatalf("Write() after Close() error = %v, want os.ErrClosed", err)
+	}
+	if err := writer.Reopen(); !errors.Is(err, os.ErrClosed) {
+		t.Fatalf("Reopen() after Close() error = %v, want os.ErrClosed", err)
+	}
+}
@@ -0,0 +1,271 @@
+package log
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+)
+
+var _ RingBufferHandler = (*ringBufferHandler)(nil)
+
+// RingBufferOptions 是 RingBufferHandler 的选项
+type RingBufferOptions struct {
+	configuration LoggerOptionsFetcher // 渲染日志记录所使用的配置
+	leveler       Leveler              // 环形缓冲区的日志级别
+	maxRecords    int                  // 最大保留的日志记录数量
+	maxBytes      int                  // 最大保留的日志字节数
+}
+
+// NewRingBufferOptions 创建一个默认的 RingBufferOptions
+//   - 默认使用生产环境配置渲染日志记录，保留 LevelDebug 及以上级别的最近 2000 条日志记录
+func NewRingBufferOptions() *RingBufferOptions {
+	return &RingBufferOptions{
+		configuration: GetConfigBuilder().Production(),
+		leveler:       LevelDebug,
+		maxRecords:    2000,
+	}
+}
+
+// WithConfiguration 设置渲染日志记录所使用的配置，配置中的日志级别与日志写入器将被忽略
+func (o *RingBufferOptions) WithConfiguration(configuration LoggerOptionsFetcher) *RingBufferOptions {
+	o.configuration = configuration
+	return o
+}
+
+// WithLeveler 设置环形缓冲区的日志级别，它与主日志记录器的级别无关
+func (o *RingBufferOptions) WithLeveler(leveler Leveler) *RingBufferOptions {
+	o.leveler = leveler
+	return o
+}
+
+// WithMaxRecords 设置最大保留的日志记录数量，当 max <= 0 时表示不限制数量
+func (o *RingBufferOptions) WithMaxRecords(max int) *RingBufferOptions {
+	o.maxRecords = max
+	return o
+}
+
+// WithMaxBytes 设置最大保留的日志字节数，当 max <= 0 时表示不限制字节数
+//   - 单条超过该字节数的日志记录将不会被保留
+func (o *RingBufferOptions) WithMaxBytes(max int) *RingBufferOptions {
+	o.maxBytes = max
+	return o
+}
+
+// RingBufferHandler 是一个环形缓冲区日志处理器，它会以完整的细节保留最近的日志记录，并在需要时将其转储
+//   - 它通常与 Builder.Multi 组合使用，以便在主日志记录器仅输出 LevelInfo 的同时保留最近的 LevelDebug 日志
+type RingBufferHandler interface {
+	Handler
+
+	// Dump 将环形缓冲区中的日志记录按顺序写入到 writer 中
+	Dump(writer io.Writer) error
+
+	// DumpFile 将环形缓冲区中的日志记录追加写入到指定路径的文件中
+	DumpFile(path string) error
+
+	// DumpOnSignal 在接收到指定信号时将环形缓冲区中的日志记录写入到 writer 中，调用返回的函数可停止监听
+	DumpOnSignal(writer io.Writer, signals ...os.Signal) (stop func())
+
+	// DumpOnPanic 在发生 panic 时将环形缓冲区中的日志记录写入到 writer 中，并继续抛出 panic
+	//  - 该函数必须通过 defer 直接调用，例如：defer handler.DumpOnPanic(os.Stderr)
+	DumpOnPanic(writer io.Writer)
+
+	// Len 获取环形缓冲区中的日志记录数量
+	Len() int
+
+	// Size 获取环形缓冲区中的日志字节数
+	Size() int
+
+	// Reset 清空环形缓冲区
+	Reset()
+}
+
+// NewRingBufferHandler 创建一个环形缓冲区日志处理器，当 options 为 nil 时将使用默认选项
+func NewRingBufferHandler(options *RingBufferOptions) RingBufferHandler {
+	if options == nil {
+		options = NewRingBufferOptions()
+	}
+	return &ringBufferHandler{
+		ring: &ringBuffer{
+			maxRecords: options.maxRecords,
+			maxBytes:   options.maxBytes,
+		},
+		leveler: options.leveler,
+		handler: newHandler(options.configuration).(*handler),
+	}
+}
+
+type ringBufferHandler struct {
+	ring    *ringBuffer // 环形缓冲区，它在所有派生的处理器间共享
+	leveler Leveler     // 环形缓冲区的日志级别
+	handler *handler    // 用于渲染日志记录的文本处理器
+}
+
+func (h *ringBufferHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.leveler.Level() || isForced(ctx, level)
+}
+
+func (h *ringBufferHandler) Handle(ctx context.Context, record slog.Record) error {
+	if !h.Enabled(ctx, record.Level) {
+		return nil
+	}
+	ctx = withCallerFrames(ctx)
+
+	options := h.handler.options.FetchCopy()
+	record = withContextAttrs(ctx, record, options.FetchContextExtractors())
+	recordBytes, err := h.handler.format(ctx, record, options)
+	if err != nil {
+		return err
+	}
+	h.ring.push(recordBytes)
+	return nil
+}
+
+func (h *ringBufferHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &ringBufferHandler{
+		ring:    h.ring,
+		leveler: h.leveler,
+		handler: h.handler.WithAttrs(attrs).(*handler),
+	}
+}
+
+func (h *ringBufferHandler) WithGroup(name string) slog.Handler {
+	return &ringBufferHandler{
+		ring:    h.ring,
+		leveler: h.leveler,
+		handler: h.handler.WithGroup(name).(*handler),
+	}
+}
+
+func (h *ringBufferHandler) Dump(writer io.Writer) error {
+	for _, entry := range h.ring.snapshot() {
+		if _, err := writer.Write(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *ringBufferHandler) DumpFile(path string) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if err = h.Dump(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (h *ringBufferHandler) DumpOnSignal(writer io.Writer, signals ...os.Signal) (stop func()) {
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, signals...)
+	go func() {
+		for {
+			select {
+			case <-ch:
+				_ = h.Dump(writer)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
+
+func (h *ringBufferHandler) DumpOnPanic(writer io.Writer) {
+	if v := recover(); v != nil {
+		_ = h.Dump(writer)
+		panic(v)
+	}
+}
+
+func (h *ringBufferHandler) Len() int {
+	return h.ring.len()
+}
+
+func (h *ringBufferHandler) Size() int {
+	return h.ring.size()
+}
+
+func (h *ringBufferHandler) Reset() {
+	h.ring.reset()
+}
+
+// ringBuffer 是按数量及字节数限制的环形缓冲区，它是并发安全的
+type ringBuffer struct {
+	rw         sync.RWMutex
+	entries    [][]byte // 日志记录，entries[head:] 为有效数据
+	head       int      // 第一条有效日志记录的位置
+	bytes      int      // 有效日志记录的字节数
+	maxRecords int      // 最大日志记录数量
+	maxBytes   int      // 最大日志字节数
+}
+
+func (r *ringBuffer) push(entry []byte) {
+	if r.maxBytes > 0 && len(entry) > r.maxBytes {
+		return
+	}
+
+	r.rw.Lock()
+	defer r.rw.Unlock()
+
+	for r.head < len(r.entries) {
+		overRecords := r.maxRecords > 0 && len(r.entries)-r.head >= r.maxRecords
+		overBytes := r.maxBytes > 0 && r.bytes+len(entry) > r.maxBytes
+		if !overRecords && !overBytes {
+			break
+		}
+		r.bytes -= len(r.entries[r.head])
+		r.entries[r.head] = nil
+		r.head++
+	}
+
+	// 当失效的空间过半时进行压缩，避免底层数组无限增长
+	if r.head > 0 && r.head >= len(r.entries)/2 {
+		n := copy(r.entries, r.entries[r.head:])
+		clear(r.entries[n:])
+		r.entries = r.entries[:n]
+		r.head = 0
+	}
+
+	r.entries = append(r.entries, entry)
+	r.bytes += len(entry)
+}
+
+func (r *ringBuffer) snapshot() [][]byte {
+	r.rw.RLock()
+	defer r.rw.RUnlock()
+	return append([][]byte(nil), r.entries[r.head:]...)
+}
+
+func (r *ringBuffer) len() int {
+	r.rw.RLock()
+	defer r.rw.RUnlock()
+	return len(r.entries) - r.head
+}
+
+func (r *ringBuffer) size() int {
+	r.rw.RLock()
+	defer r.rw.RUnlock()
+	return r.bytes
+}
+
+func (r *ringBuffer) reset() {
+	r.rw.Lock()
+	defer r.rw.Unlock()
+	r.entries = nil
+	r.head = 0
+	r.bytes = 0
+}
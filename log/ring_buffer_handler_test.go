@@ -0,0 +1,70 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestRingBufferHandler tests that the ring buffer retains debug records regardless of the main level
+// and evicts the oldest records when the limit is reached.
+func TestRingBufferHandler(t *testing.T) {
+	var main, dump bytes.Buffer
+	ring := NewRingBufferHandler(NewRingBufferOptions().
+		WithConfiguration(GetConfigBuilder().Test().WithCallerSkip(6)).
+		WithMaxRecords(3))
+
+	builder := GetBuilder()
+	logger := builder.Multi(
+		builder.FromConfiguration(GetConfigBuilder().Production().WithWriter(&main)),
+		builder.FromHandler(ring),
+	)
+
+	for i := 0; i < 5; i++ {
+		logger.Debug("debug", "i", i)
+	}
+	logger.Info("info")
+
+	if strings.Contains(main.String(), "Debug") {
+		t.Fatalf("main output contains debug records: %s", main.String())
+	}
+	if ring.Len() != 3 {
+		t.Fatalf("ring buffer length = %d, want 3", ring.Len())
+	}
+	if err := ring.Dump(&dump); err != nil {
+		t.Fatalf("Dump() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(dump.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("dump lines = %d, want 3", len(lines))
+	}
+	if !strings.HasSuffix(lines[0], "3") || !strings.Contains(lines[2], "Info") {
+		t.Fatalf("unexpected dump order: %s", dump.String())
+	}
+	if !strings.Contains(lines[0], "ring_buffer_handler_test.go") {
+		t.Fatalf("caller is not resolved through multi handler: %s", lines[0])
+	}
+}
+
+// TestRingBufferHandlerMaxBytes tests that the ring buffer evicts records by size.
+func TestRingBufferHandlerMaxBytes(t *testing.T) {
+	ring := NewRingBufferHandler(NewRingBufferOptions().WithMaxRecords(0).WithMaxBytes(256))
+	logger := GetBuilder().FromHandler(ring)
+	for i := 0; i < 100; i++ {
+		logger.Debug("message", "i", i)
+	}
+
+	if ring.Size() > 256 {
+		t.Fatalf("ring buffer size = %d, want <= 256", ring.Size())
+	}
+	if ring.Len() == 0 {
+		t.Fatal("ring buffer is empty")
+	}
+
+	before := ring.Len()
+	logger.Debug("oversized", "payload", strings.Repeat("x", 512))
+	if ring.Size() > 256 || ring.Len() != before {
+		t.Fatalf("oversized record is retained: size = %d, len = %d, want <= 256 and %d", ring.Size(), ring.Len(), before)
+	}
+}
@@ -0,0 +1,219 @@
+package log
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var _ SamplingHandler = (*samplingHandler)(nil)
+
+// SamplingOptions 是 SamplingHandler 的选项
+type SamplingOptions struct {
+	tick       time.Duration // 采样周期
+	first      int           // 每个周期内每个级别及消息放行的前 N 条日志记录
+	thereafter int           // 超出前 N 条后每 M 条放行一条
+}
+
+// NewSamplingOptions 创建一个默认的 SamplingOptions
+//   - 默认在每秒内为每个级别及消息放行前 100 条日志记录，此后每 100 条放行一条
+func NewSamplingOptions() *SamplingOptions {
+	return &SamplingOptions{
+		tick:       time.Second,
+		first:      100,
+		thereafter: 100,
+	}
+}
+
+// WithTick 设置采样周期
+func (o *SamplingOptions) WithTick(tick time.Duration) *SamplingOptions {
+	o.tick = tick
+	return o
+}
+
+// WithFirst 设置每个周期内每个级别及消息放行的前 N 条日志记录
+func (o *SamplingOptions) WithFirst(first int) *SamplingOptions {
+	o.first = first
+	return o
+}
+
+// WithThereafter 设置超出前 N 条后每 M 条放行一条，当 m <= 0 时将丢弃超出的所有日志记录
+func (o *SamplingOptions) WithThereafter(m int) *SamplingOptions {
+	o.thereafter = m
+	return o
+}
+
+// SamplingHandler 是一个采样日志处理器，它会在每个周期内为每个分组、级别及消息放行前 N 条日志记录，此后每 M 条放行一条
+//   - 分组为通过 WithGroup 构建的分组路径，不同分组下的相同消息将被分别计数
+//   - 当一个周期结束后，将为每个存在丢弃的分组、级别及消息记录一条摘要，其中包含被丢弃的日志记录数量
+//   - 摘要在周期结束后的下一条日志记录到达时记录，因此最后一个周期的摘要需要通过 Flush 记录，例如在程序退出前调用
+type SamplingHandler interface {
+	Handler
+
+	// Flush 立即为当前周期内存在丢弃的级别及消息记录摘要，并开始一个新的周期
+	Flush()
+
+	// Sampled 获取被放行的日志记录数量
+	Sampled() uint64
+
+	// Dropped 获取被丢弃的日志记录数量
+	Dropped() uint64
+}
+
+// NewSamplingHandler 创建一个包装 handler 的采样日志处理器，当 options 为 nil 时将使用默认选项
+func NewSamplingHandler(handler Handler, options *SamplingOptions) SamplingHandler {
+	if options == nil {
+		options = NewSamplingOptions()
+	}
+	tick := options.tick
+	if tick <= 0 {
+		tick = NewSamplingOptions().tick
+	}
+	return &samplingHandler{
+		state: &samplingState{
+			handler:    handler,
+			tick:       tick,
+			first:      uint64(max(options.first, 0)),
+			thereafter: uint64(max(options.thereafter, 0)),
+			counters:   make(map[samplingKey]*samplingCounter),
+		},
+		handler: handler,
+	}
+}
+
+type samplingHandler struct {
+	state   *samplingState // 采样状态，它在所有派生的处理器间共享
+	handler slog.Handler   // 被包装的处理器
+	group   string         // 通过 WithGroup 构建的分组路径
+}
+
+func (h *samplingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *samplingHandler) Handle(ctx context.Context, record slog.Record) error {
+	ctx = withCallerFrames(ctx)
+	ctx, record = resolveContextAttrs(ctx, record)
+	if !h.state.sample(h.group, record) {
+		return nil
+	}
+	return h.handler.Handle(ctx, record)
+}
+
+func (h *samplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &samplingHandler{state: h.state, handler: h.handler.WithAttrs(attrs), group: h.group}
+}
+
+func (h *samplingHandler) WithGroup(name string) slog.Handler {
+	n := &samplingHandler{state: h.state, handler: h.handler.WithGroup(name), group: name}
+	if h.group != "" {
+		n.group = h.group + "." + name
+	}
+	return n
+}
+
+func (h *samplingHandler) Flush() {
+	h.state.flush()
+}
+
+func (h *samplingHandler) Sampled() uint64 {
+	return h.state.sampled.Load()
+}
+
+func (h *samplingHandler) Dropped() uint64 {
+	return h.state.dropped.Load()
+}
+
+type samplingKey struct {
+	group string
+	level slog.Level
+	msg   string
+}
+
+type samplingCounter struct {
+	count   uint64 // 当前周期内的日志记录数量
+	dropped uint64 // 当前周期内被丢弃的日志记录数量
+}
+
+type samplingState struct {
+	rw         sync.Mutex
+	handler    slog.Handler                     // 用于记录摘要的处理器
+	tick       time.Duration                    // 采样周期
+	first      uint64                           // 每个周期内放行的前 N 条日志记录
+	thereafter uint64                           // 超出前 N 条后每 M 条放行一条
+	tickEnd    time.Time                        // 当前周期的结束时间
+	counters   map[samplingKey]*samplingCounter // 当前周期内的计数器
+	sampled    atomic.Uint64                    // 被放行的日志记录数量
+	dropped    atomic.Uint64                    // 被丢弃的日志记录数量
+}
+
+// sample 判断日志记录是否应当被放行，当周期结束时将记录上一个周期的摘要
+func (s *samplingState) sample(group string, record slog.Record) bool {
+	now := record.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	s.rw.Lock()
+	var expired map[samplingKey]*samplingCounter
+	if !now.Before(s.tickEnd) {
+		expired = s.counters
+		s.counters = make(map[samplingKey]*samplingCounter)
+		s.tickEnd = now.Truncate(s.tick).Add(s.tick)
+	}
+
+	key := samplingKey{group: group, level: record.Level, msg: record.Message}
+	counter, exist := s.counters[key]
+	if !exist {
+		counter = new(samplingCounter)
+		s.counters[key] = counter
+	}
+	counter.count++
+	keep := counter.count <= s.first || (s.thereafter > 0 && (counter.count-s.first)%s.thereafter == 0)
+	if !keep {
+		counter.dropped++
+	}
+	s.rw.Unlock()
+
+	s.report(expired)
+	if keep {
+		s.sampled.Add(1)
+	} else {
+		s.dropped.Add(1)
+	}
+	return keep
+}
+
+// flush 结束当前周期并记录摘要
+func (s *samplingState) flush() {
+	s.rw.Lock()
+	expired := s.counters
+	s.counters = make(map[samplingKey]*samplingCounter)
+	s.tickEnd = time.Time{}
+	s.rw.Unlock()
+
+	s.report(expired)
+}
+
+// report 为上一个周期内存在丢弃的分组、级别及消息记录摘要，摘要不属于任何调用者，因此使用中立的上下文
+func (s *samplingState) report(expired map[samplingKey]*samplingCounter) {
+	ctx := withoutCallerFrames(context.Background())
+	for key, counter := range expired {
+		if counter.dropped == 0 || !s.handler.Enabled(ctx, key.level) {
+			continue
+		}
+		summary := slog.NewRecord(time.Now(), key.level, "log sampling suppressed records", 0)
+		if key.group != "" {
+			summary.AddAttrs(slog.String("sampled_group", key.group))
+		}
+		summary.AddAttrs(
+			slog.String("sampled_msg", key.msg),
+			slog.Uint64("suppressed", counter.dropped),
+			slog.Uint64("total", counter.count),
+			slog.Duration("tick", s.tick),
+		)
+		_ = s.handler.Handle(ctx, summary)
+	}
+}
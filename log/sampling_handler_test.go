@@ -0,0 +1,109 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSamplingHandler tests first-N-then-every-M counting per level and message, and that counters restart each interval.
+func TestSamplingHandler(t *testing.T) {
+	var buf bytes.Buffer
+	handler := NewSamplingHandler(newHandler(GetConfigBuilder().Test().WithWriter(&buf)), NewSamplingOptions().WithTick(time.Minute).WithFirst(2).WithThereafter(3))
+
+	start := time.Now().Truncate(time.Minute)
+	kept := func(at time.Duration, level slog.Level, msg string, n int) int {
+		before := handler.Sampled()
+		for i := 0; i < n; i++ {
+			_ = handler.Handle(context.Background(), slog.NewRecord(start.Add(at), level, msg, 0))
+		}
+		return int(handler.Sampled() - before)
+	}
+
+	// 1, 2, 5 and 8 are kept
+	if got := kept(0, LevelInfo, "hot", 10); got != 4 {
+		t.Fatalf("first interval kept %d records, want 4", got)
+	}
+	if got := kept(time.Second, LevelWarn, "hot", 2); got != 2 {
+		t.Fatalf("another level kept %d records, want 2", got)
+	}
+	if got := kept(time.Minute, LevelInfo, "hot", 3); got != 2 {
+		t.Fatalf("next interval kept %d records, want 2", got)
+	}
+	if handler.Sampled() != 8 || handler.Dropped() != 7 {
+		t.Fatalf("Sampled() = %d, Dropped() = %d, want 8 and 7", handler.Sampled(), handler.Dropped())
+	}
+}
+
+// TestSamplingHandlerSummary tests that a summary is recorded for the previous interval once a new one starts, and on Flush.
+func TestSamplingHandlerSummary(t *testing.T) {
+	var buf bytes.Buffer
+	handler := NewSamplingHandler(newHandler(GetConfigBuilder().Test().WithWriter(&buf)), NewSamplingOptions().WithTick(time.Minute).WithFirst(1).WithThereafter(0))
+
+	start := time.Now().Truncate(time.Minute)
+	for i := 0; i < 5; i++ {
+		_ = handler.Handle(context.Background(), slog.NewRecord(start, LevelInfo, "hot", 0))
+	}
+	summaries := func() []string {
+		var lines []string
+		plain := regexp.MustCompile("\x1b+\\[[0-9;]*m").ReplaceAllString(buf.String(), "")
+		for _, line := range strings.Split(plain, "\n") {
+			if strings.Contains(line, "Log sampling suppressed records") {
+				lines = append(lines, line)
+			}
+		}
+		return lines
+	}
+	if len(summaries()) != 0 {
+		t.Fatalf("summary is recorded before the interval ends: %s", buf.String())
+	}
+
+	for i := 0; i < 3; i++ {
+		_ = handler.Handle(context.Background(), slog.NewRecord(start.Add(time.Minute), LevelInfo, "hot", 0))
+	}
+	handler.Flush()
+
+	lines := summaries()
+	if len(lines) != 2 {
+		t.Fatalf("got %d summaries, want 2: %s", len(lines), buf.String())
+	}
+	for i, want := range []string{
+		`sampled_msg="hot" suppressed=4 total=5 tick="1m0s"`,
+		`sampled_msg="hot" suppressed=2 total=3 tick="1m0s"`,
+	} {
+		if !strings.Contains(lines[i], want) {
+			t.Fatalf("summary %q misses %q", lines[i], want)
+		}
+	}
+	if strings.Contains(lines[0], "sampling_handler_test.go") {
+		t.Fatalf("summary carries a caller: %s", lines[0])
+	}
+}
+
+// TestSamplingHandlerGroup tests that the same message is counted separately per group and that summaries carry the group.
+func TestSamplingHandlerGroup(t *testing.T) {
+	var buf bytes.Buffer
+	handler := NewSamplingHandler(newHandler(GetConfigBuilder().Test().WithWriter(&buf)), NewSamplingOptions().WithTick(time.Minute).WithFirst(1).WithThereafter(0))
+	payment := handler.WithGroup("payment").WithGroup("api")
+	order := handler.WithGroup("order")
+
+	start := time.Now().Truncate(time.Minute)
+	for _, h := range []slog.Handler{payment, order, payment, order} {
+		_ = h.Handle(context.Background(), slog.NewRecord(start, LevelInfo, "hot", 0))
+	}
+	if handler.Sampled() != 2 || handler.Dropped() != 2 {
+		t.Fatalf("Sampled() = %d, Dropped() = %d, want 2 and 2", handler.Sampled(), handler.Dropped())
+	}
+
+	handler.Flush()
+	plain := regexp.MustCompile("\x1b+\\[[0-9;]*m").ReplaceAllString(buf.String(), "")
+	for _, group := range []string{`sampled_group="payment.api"`, `sampled_group="order"`} {
+		if !strings.Contains(plain, group) {
+			t.Fatalf("summaries miss %s: %s", group, plain)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/kercylan98/go-log/log/internal/charproc"
+	"log/slog"
+	"strings"
+)
+
+var _ Handler = (*transformHandler)(nil)
+
+// KeyCase 是属性键的命名风格
+type KeyCase int
+
+const (
+	KeyCaseKeep     KeyCase = iota // 保持属性键不变
+	KeyCaseSnake                   // 蛇形命名，例如 user_id
+	KeyCaseCamel                   // 驼峰命名，例如 userId
+	KeyCaseBigCamel                // 大驼峰命名，例如 UserId
+)
+
+// TransformOptions 是 TransformHandler 的规则
+//   - 属性路径以 "." 连接分组及属性键，例如 "http.status"，它相对于处理器通过 WithGroup 所处的分组，因此同一规则对所有派生的处理器生效
+//   - 规则匹配原始的属性路径，即在转换命名风格之前的属性路径
+type TransformOptions struct {
+	renames []transformRename // 重命名规则
+	drops   []string          // 丢弃规则
+	keyCase KeyCase           // 属性键的命名风格
+}
+
+type transformRename struct {
+	from, to string
+}
+
+// NewTransformOptions 创建一个默认的 TransformOptions
+//   - 默认不包含任何规则
+func NewTransformOptions() *TransformOptions {
+	return &TransformOptions{}
+}
+
+// WithRename 将路径为 from 的属性移动到路径 to，它可以用于重命名属性，或将属性移入及移出分组
+//   - 例如 WithRename("userId", "user.id") 将属性移入 user 分组，WithRename("http.status", "status") 将属性移出 http 分组
+//   - 目标路径不会被转换命名风格
+func (o *TransformOptions) WithRename(from, to string) *TransformOptions {
+	o.renames = append(o.renames, transformRename{from: from, to: to})
+	return o
+}
+
+// WithDrop 丢弃指定路径的属性，当路径指向分组时，将丢弃整个分组
+func (o *TransformOptions) WithDrop(paths ...string) *TransformOptions {
+	o.drops = append(o.drops, paths...)
+	return o
+}
+
+// WithKeyCase 设置属性键及分组名称的命名风格
+func (o *TransformOptions) WithKeyCase(keyCase KeyCase) *TransformOptions {
+	o.keyCase = keyCase
+	return o
+}
+
+// NewTransformHandler 创建一个包装 handler 的属性转换日志处理器，规则将在创建时被编译，当规则无效时将返回错误
+//   - 规则将同时应用于 WithAttrs 中的属性及日志记录中的属性，属性的移动仅在同一次 WithAttrs 或同一条日志记录的属性中进行
+//
+// 当需要为 Multi 构建的 Logger 的每个分支定义不同的规则时，应当分别包装每个分支的 Handler，例如：
+//
+//	snake, _ := NewTransformHandler(a.Handler(), NewTransformOptions().WithKeyCase(KeyCaseSnake))
+//	logger := GetBuilder().Multi(GetBuilder().FromHandler(snake), b)
+func NewTransformHandler(handler Handler, options *TransformOptions) (Handler, error) {
+	if options == nil {
+		options = NewTransformOptions()
+	}
+	t := &transformer{
+		renames: make(map[string][]string, len(options.renames)),
+		drops:   make(map[string]struct{}, len(options.drops)),
+	}
+	switch options.keyCase {
+	case KeyCaseSnake:
+		t.keyCase = charproc.Snake
+	case KeyCaseCamel:
+		t.keyCase = func(key string) string { return charproc.Camel(charproc.Snake(key)) }
+	case KeyCaseBigCamel:
+		t.keyCase = func(key string) string { return charproc.BigCamel(charproc.Snake(key)) }
+	default:
+		t.keyCase = func(key string) string { return key }
+	}
+
+	var errs []error
+	for _, path := range options.drops {
+		if !isTransformPath(path) {
+			errs = append(errs, fmt.Errorf("log transform: invalid drop path %q", path))
+			continue
+		}
+		t.drops[path] = struct{}{}
+	}
+	for _, rename := range options.renames {
+		switch {
+		case !isTransformPath(rename.from) || !isTransformPath(rename.to):
+			errs = append(errs, fmt.Errorf("log transform: invalid rename %q -> %q", rename.from, rename.to))
+		case t.renames[rename.from] != nil:
+			errs = append(errs, fmt.Errorf("log transform: duplicate rename of %q", rename.from))
+		default:
+			if _, dropped := t.drops[rename.from]; dropped {
+				errs = append(errs, fmt.Errorf("log transform: %q is both renamed and dropped", rename.from))
+				continue
+			}
+			t.renames[rename.from] = strings.Split(rename.to, ".")
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return &transformHandler{transformer: t, handler: handler}, nil
+}
+
+// isTransformPath 检查属性路径是否有效，即不包含空的分组或属性键
+func isTransformPath(path string) bool {
+	for _, segment := range strings.Split(path, ".") {
+		if segment == "" {
+			return false
+		}
+	}
+	return true
+}
+
+type transformHandler struct {
+	transformer *transformer // 编译后的规则，它在所有派生的处理器间共享
+	handler     slog.Handler // 被包装的处理器
+}
+
+func (h *transformHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *transformHandler) Handle(ctx context.Context, record slog.Record) error {
+	ctx = withCallerFrames(ctx)
+	ctx, record = resolveContextAttrs(ctx, record)
+	attrs := make([]slog.Attr, 0, record.NumAttrs())
+	record.Attrs(func(attr slog.Attr) bool {
+		attrs = append(attrs, attr)
+		return true
+	})
+	transformed := slog.NewRecord(record.Time, record.Level, record.Message, record.PC)
+	transformed.AddAttrs(h.transformer.apply(attrs)...)
+	return h.handler.Handle(ctx, transformed)
+}
+
+func (h *transformHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &transformHandler{transformer: h.transformer, handler: h.handler.WithAttrs(h.transformer.apply(attrs))}
+}
+
+func (h *transformHandler) WithGroup(name string) slog.Handler {
+	return &transformHandler{transformer: h.transformer, handler: h.handler.WithGroup(h.transformer.keyCase(name))}
+}
+
+// transformer 是编译后的转换规则
+type transformer struct {
+	renames map[string][]string     // 原始属性路径 -> 目标属性路径
+	drops   map[string]struct{}     // 被丢弃的原始属性路径
+	keyCase func(key string) string // 属性键的命名风格转换函数
+}
+
+// apply 转换属性，被移动的属性将被插入到转换后的属性中
+func (t *transformer) apply(attrs []slog.Attr) []slog.Attr {
+	var moved []transformMove
+	result := t.walk("", attrs, &moved)
+	for _, move := range moved {
+		result = transformInsert(result, move.path, move.value)
+	}
+	return result
+}
+
+type transformMove struct {
+	path  []string
+	value slog.Value
+}
+
+func (t *transformer) walk(prefix string, attrs []slog.Attr, moved *[]transformMove) []slog.Attr {
+	result := make([]slog.Attr, 0, len(attrs))
+	for _, attr := range attrs {
+		attr.Value = attr.Value.Resolve()
+		path := attr.Key
+		if prefix != "" {
+			path = prefix + "." + attr.Key
+		}
+		if _, dropped := t.drops[path]; dropped {
+			continue
+		}
+
+		value := attr.Value
+		if value.Kind() == slog.KindGroup {
+			value = slog.GroupValue(t.walk(path, value.Group(), moved)...)
+		}
+		if to, renamed := t.renames[path]; renamed {
+			*moved = append(*moved, transformMove{path: to, value: value})
+			continue
+		}
+		if value.Kind() == slog.KindGroup && len(value.Group()) == 0 {
+			continue
+		}
+		if attr.Key == "" {
+			// 空键的分组属性会被内联，其中的属性保持在当前的分组中
+			result = append(result, slog.Attr{Value: value})
+			continue
+		}
+		result = append(result, slog.Attr{Key: t.keyCase(attr.Key), Value: value})
+	}
+	return result
+}
+
+// transformInsert 将属性插入到 attrs 中的指定路径，路径中不存在的分组将被创建
+func transformInsert(attrs []slog.Attr, path []string, value slog.Value) []slog.Attr {
+	if len(path) == 1 {
+		return append(attrs, slog.Attr{Key: path[0], Value: value})
+	}
+	for i, attr := range attrs {
+		if attr.Key == path[0] && attr.Value.Kind() == slog.KindGroup {
+			attrs[i].Value = slog.GroupValue(transformInsert(attr.Value.Group(), path[1:], value)...)
+			return attrs
+		}
+	}
+	return append(attrs, slog.Attr{Key: path[0], Value: slog.GroupValue(transformInsert(nil, path[1:], value)...)})
+}
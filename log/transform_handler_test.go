@@ -0,0 +1,37 @@
+package log
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// TestTransformHandler tests renames into and out of groups, drops and key casing on both WithAttrs and record attrs.
+func TestTransformHandler(t *testing.T) {
+	var buf bytes.Buffer
+	options := NewTransformOptions().
+		WithRename("userId", "user.id").
+		WithRename("http.statusCode", "status").
+		WithDrop("debugInfo").
+		WithKeyCase(KeyCaseSnake)
+	transform, err := NewTransformHandler(slog.NewJSONHandler(&buf, nil), options)
+	if err != nil {
+		t.Fatalf("NewTransformHandler() error = %v", err)
+	}
+
+	logger := GetBuilder().FromHandler(transform)
+	logger.With("requestID", "r-1", "debugInfo", "x").WithGroup("apiCall").Info("done",
+		"userId", 42,
+		Group("http", "statusCode", 200, "remoteAddr", "127.0.0.1"),
+	)
+
+	want := `"request_id":"r-1","api_call":{"http":{"remote_addr":"127.0.0.1"},"user":{"id":42},"status":200}}`
+	if out := buf.String(); !strings.Contains(out, want) || strings.Contains(out, "debug") {
+		t.Fatalf("unexpected output: %s\nwant it to contain: %s", out, want)
+	}
+
+	if _, err = NewTransformHandler(transform, NewTransformOptions().WithRename("a..b", "c").WithDrop("d").WithRename("d", "e")); err == nil {
+		t.Fatal("invalid rules are accepted")
+	}
+}
@@ -0,0 +1,426 @@
+package log
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	jsonIter "github.com/json-iterator/go"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	_ WebhookHandler = (*webhookHandler)(nil)
+
+	// ErrWebhookClosed 是 WebhookHandler 关闭后继续处理日志记录时返回的错误
+	ErrWebhookClosed = errors.New("log webhook: handler closed")
+)
+
+// WebhookOptions 是 WebhookHandler 的选项
+type WebhookOptions struct {
+	client        *http.Client    // HTTP 客户端
+	leveler       Leveler         // 日志级别
+	batchSize     int             // 批次的最大日志记录数量
+	flushInterval time.Duration   // 批次的最大等待时间
+	queueSize     int             // 等待发送的日志记录队列长度
+	headers       http.Header     // 自定义请求头
+	gzip          bool            // 是否启用 gzip 压缩
+	maxRetries    int             // 最大重试次数
+	retryBackoff  time.Duration   // 初始的重试间隔
+	maxBackoff    time.Duration   // 最大的重试间隔
+	deadLetter    io.Writer       // 死信写入器
+	errorHandler  func(err error) // 发送失败时的错误处理函数
+}
+
+// NewWebhookOptions 创建一个默认的 WebhookOptions
+//   - 默认记录 LevelInfo 及以上级别的日志，每批次最多 100 条日志记录，最长等待 1 秒，失败后最多重试 3 次，重试间隔最长 30 秒
+func NewWebhookOptions() *WebhookOptions {
+	return &WebhookOptions{
+		client:        &http.Client{Timeout: 10 * time.Second},
+		leveler:       LevelInfo,
+		batchSize:     100,
+		flushInterval: time.Second,
+		queueSize:     1024,
+		headers:       make(http.Header),
+		maxRetries:    3,
+		retryBackoff:  500 * time.Millisecond,
+		maxBackoff:    30 * time.Second,
+	}
+}
+
+// WithClient 设置发送请求所使用的 HTTP 客户端
+func (o *WebhookOptions) WithClient(client *http.Client) *WebhookOptions {
+	o.client = client
+	return o
+}
+
+// WithLeveler 设置日志级别
+func (o *WebhookOptions) WithLeveler(leveler Leveler) *WebhookOptions {
+	o.leveler = leveler
+	return o
+}
+
+// WithBatchSize 设置批次的最大日志记录数量，达到该数量时将立即发送
+func (o *WebhookOptions) WithBatchSize(size int) *WebhookOptions {
+	o.batchSize = size
+	return o
+}
+
+// WithFlushInterval 设置批次的最大等待时间，超过该时间时将发送未满的批次
+func (o *WebhookOptions) WithFlushInterval(interval time.Duration) *WebhookOptions {
+	o.flushInterval = interval
+	return o
+}
+
+// WithQueueSize 设置等待发送的日志记录队列长度，当队列已满时日志记录将被丢弃，并可通过 WebhookHandler.Dropped 获取丢弃的数量
+//   - 当 size <= 0 时将使用默认的队列长度
+func (o *WebhookOptions) WithQueueSize(size int) *WebhookOptions {
+	o.queueSize = size
+	return o
+}
+
+// WithHeader 设置自定义请求头
+func (o *WebhookOptions) WithHeader(key, value string) *WebhookOptions {
+	o.headers.Set(key, value)
+	return o
+}
+
+// WithGzip 设置是否启用 gzip 压缩请求体
+func (o *WebhookOptions) WithGzip(enable bool) *WebhookOptions {
+	o.gzip = enable
+	return o
+}
+
+// WithRetry 设置最大重试次数及初始的重试间隔，重试间隔将按指数增长
+//   - 仅在网络错误、5xx 及 429 响应时重试，当响应包含 Retry-After 时将以其为准，但不会超过最大的重试间隔
+//   - 在等待重试期间调用 Close 将放弃等待，此时该批次将被视为发送失败
+func (o *WebhookOptions) WithRetry(maxRetries int, backoff time.Duration) *WebhookOptions {
+	o.maxRetries = maxRetries
+	o.retryBackoff = backoff
+	return o
+}
+
+// WithMaxBackoff 设置最大的重试间隔，指数增长的重试间隔及 Retry-After 均不会超过该值，当 backoff <= 0 时表示不限制
+func (o *WebhookOptions) WithMaxBackoff(backoff time.Duration) *WebhookOptions {
+	o.maxBackoff = backoff
+	return o
+}
+
+// WithDeadLetter 设置死信写入器，重试后仍然发送失败的批次将以 JSON 数组的形式逐行写入其中
+func (o *WebhookOptions) WithDeadLetter(writer io.Writer) *WebhookOptions {
+	o.deadLetter = writer
+	return o
+}
+
+// WithErrorHandler 设置批次发送失败时的错误处理函数
+func (o *WebhookOptions) WithErrorHandler(handler func(err error)) *WebhookOptions {
+	o.errorHandler = handler
+	return o
+}
+
+// WebhookHandler 是一个以批次的形式通过 HTTP POST 发送 JSON 数组的日志处理器
+//   - 日志记录在调用者的协程中被序列化，随后由后台协程按批次发送
+type WebhookHandler interface {
+	Handler
+
+	// Flush 立即发送所有等待中的日志记录，并等待发送完成
+	Flush() error
+
+	// Close 发送所有等待中的日志记录并停止后台协程，关闭后的日志记录将被拒绝
+	Close() error
+
+	// Dropped 获取由于队列已满而被丢弃的日志记录数量
+	Dropped() uint64
+}
+
+// NewWebhookHandler 创建一个发送到 url 的 WebhookHandler，当 options 为 nil 时将使用默认选项
+func NewWebhookHandler(url string, options *WebhookOptions) WebhookHandler {
+	if options == nil {
+		options = NewWebhookOptions()
+	}
+	queueSize := options.queueSize
+	if queueSize <= 0 {
+		queueSize = NewWebhookOptions().queueSize
+	}
+	s := &webhookSender{
+		url:     url,
+		options: options,
+		records: make(chan []byte, queueSize),
+		flushes: make(chan chan error),
+		closing: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go s.run()
+	return &webhookHandler{sender: s}
+}
+
+type webhookHandler struct {
+	sender *webhookSender // 发送器，它在所有派生的处理器间共享
+	attrs  []slog.Attr
+	group  string
+}
+
+func (h *webhookHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.sender.options.leveler.Level()
+}
+
+func (h *webhookHandler) Handle(ctx context.Context, record slog.Record) error {
+	if !h.Enabled(ctx, record.Level) {
+		return nil
+	}
+	record = withContextAttrs(ctx, record, nil)
+	data, err := jsonIter.ConfigCompatibleWithStandardLibrary.Marshal(recordToMap(h.group, h.attrs, record))
+	if err != nil {
+		return err
+	}
+	return h.sender.enqueue(data)
+}
+
+func (h *webhookHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &webhookHandler{
+		sender: h.sender,
+		attrs:  append(h.attrs[:len(h.attrs):len(h.attrs)], attrs...),
+		group:  h.group,
+	}
+}
+
+func (h *webhookHandler) WithGroup(name string) slog.Handler {
+	n := &webhookHandler{sender: h.sender, attrs: h.attrs, group: name}
+	if h.group != "" {
+		n.group = h.group + "." + name
+	}
+	return n
+}
+
+func (h *webhookHandler) Flush() error {
+	return h.sender.flush()
+}
+
+func (h *webhookHandler) Close() error {
+	return h.sender.close()
+}
+
+func (h *webhookHandler) Dropped() uint64 {
+	return h.sender.dropped.Load()
+}
+
+type webhookSender struct {
+	url     string
+	options *WebhookOptions
+	records chan []byte     // 等待发送的日志记录，它不会被关闭，以免与并发的写入产生竞争
+	flushes chan chan error // 立即发送请求
+	closing chan struct{}   // 关闭通道，它被用于停止后台协程及中断重试的等待
+	done    chan struct{}   // 后台协程结束通道
+	closed  atomic.Bool
+	dropped atomic.Uint64 // 由于队列已满而被丢弃的日志记录数量
+}
+
+func (s *webhookSender) enqueue(data []byte) error {
+	if s.closed.Load() {
+		return ErrWebhookClosed
+	}
+	select {
+	case s.records <- data:
+	default:
+		s.dropped.Add(1)
+	}
+	return nil
+}
+
+func (s *webhookSender) flush() error {
+	if s.closed.Load() {
+		return ErrWebhookClosed
+	}
+	result := make(chan error, 1)
+	select {
+	case s.flushes <- result:
+		return <-result
+	case <-s.closing:
+		return ErrWebhookClosed
+	}
+}
+
+func (s *webhookSender) close() error {
+	if !s.closed.CompareAndSwap(false, true) {
+		return ErrWebhookClosed
+	}
+	close(s.closing)
+	<-s.done
+	return nil
+}
+
+func (s *webhookSender) run() {
+	defer close(s.done)
+
+	batchSize := max(s.options.batchSize, 1)
+	batch := make([][]byte, 0, batchSize)
+	send := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		err := s.send(batch)
+		batch = batch[:0]
+		return err
+	}
+
+	var tick <-chan time.Time
+	if s.options.flushInterval > 0 {
+		ticker := time.NewTicker(s.options.flushInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	// drain 取出队列中已有的日志记录并发送，保证 Flush 及 Close 前的日志记录均被发送
+	drain := func() (err error) {
+		for {
+			select {
+			case data := <-s.records:
+				batch = append(batch, data)
+				if len(batch) >= batchSize {
+					err = errors.Join(err, send())
+				}
+			default:
+				return errors.Join(err, send())
+			}
+		}
+	}
+
+	for {
+		select {
+		case data := <-s.records:
+			batch = append(batch, data)
+			if len(batch) >= batchSize {
+				_ = send()
+			}
+		case <-tick:
+			_ = send()
+		case result := <-s.flushes:
+			result <- drain()
+		case <-s.closing:
+			_ = drain()
+			return
+		}
+	}
+}
+
+// send 发送一个批次，重试失败后将写入死信写入器
+func (s *webhookSender) send(batch [][]byte) error {
+	var body bytes.Buffer
+	body.WriteByte('[')
+	for i, data := range batch {
+		if i > 0 {
+			body.WriteByte(',')
+		}
+		body.Write(data)
+	}
+	body.WriteByte(']')
+
+	err := s.post(body.Bytes())
+	if err == nil {
+		return nil
+	}
+
+	err = fmt.Errorf("log webhook: send %d records to %s: %w", len(batch), s.url, err)
+	if s.options.deadLetter != nil {
+		if _, dlErr := s.options.deadLetter.Write(append(body.Bytes(), '\n')); dlErr != nil {
+			err = errors.Join(err, dlErr)
+		}
+	}
+	if s.options.errorHandler != nil {
+		s.options.errorHandler(err)
+	}
+	return err
+}
+
+func (s *webhookSender) post(payload []byte) error {
+	if s.options.gzip {
+		var compressed bytes.Buffer
+		zw := gzip.NewWriter(&compressed)
+		if _, err := zw.Write(payload); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+		payload = compressed.Bytes()
+	}
+
+	backoff := s.options.retryBackoff
+	for attempt := 0; ; attempt++ {
+		retryAfter, err := s.postOnce(payload)
+		if err == nil {
+			return nil
+		}
+		if retryAfter < 0 || attempt >= s.options.maxRetries {
+			return err
+		}
+		if retryAfter == 0 {
+			retryAfter = backoff
+			backoff *= 2
+		}
+		if s.options.maxBackoff > 0 {
+			retryAfter = min(retryAfter, s.options.maxBackoff)
+		}
+
+		timer := time.NewTimer(retryAfter)
+		select {
+		case <-timer.C:
+		case <-s.closing:
+			timer.Stop()
+			return err
+		}
+	}
+}
+
+// postOnce 发送一次请求，当 retryAfter < 0 时表示不应重试，当 retryAfter == 0 时表示使用默认的重试间隔
+func (s *webhookSender) postOnce(payload []byte) (retryAfter time.Duration, err error) {
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(payload))
+	if err != nil {
+		return -1, err
+	}
+	for key, values := range s.options.headers {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.options.gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
+	resp, err := s.options.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return 0, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return parseRetryAfter(resp.Header.Get("Retry-After")), fmt.Errorf("unexpected status %s", resp.Status)
+	default:
+		return -1, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+}
+
+// parseRetryAfter 解析 Retry-After 响应头，它可以是秒数或 HTTP 日期，无法解析时返回 0
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
@@ -0,0 +1,225 @@
+package log
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestWebhookHandler tests batching, gzip, custom headers and retries honoring Retry-After.
+func TestWebhookHandler(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		batches  [][]map[string]any
+		requests atomic.Int32
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		if r.Header.Get("X-Token") != "secret" || r.Header.Get("Content-Encoding") != "gzip" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var batch []map[string]any
+		if err = json.NewDecoder(zr).Decode(&batch); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		batches = append(batches, batch)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	handler := NewWebhookHandler(server.URL, NewWebhookOptions().
+		WithBatchSize(2).
+		WithFlushInterval(time.Hour).
+		WithHeader("X-Token", "secret").
+		WithGzip(true).
+		WithRetry(1, time.Millisecond))
+	logger := GetBuilder().FromHandler(handler).WithGroup("api").With("service", "billing")
+
+	start := time.Now()
+	logger.Info("first", "i", 1)
+	logger.Info("second", "i", 2)
+	logger.Warn("third", "i", 3)
+	if err := handler.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if time.Since(start) < time.Second {
+		t.Fatal("Retry-After is not honored")
+	}
+	if err := handler.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(batches) != 2 || len(batches[0]) != 2 || len(batches[1]) != 1 {
+		t.Fatalf("unexpected batches: %v", batches)
+	}
+	if r := batches[0][0]; r["msg"] != "first" || r["group"] != "api" || r["service"] != "billing" || r["i"] != float64(1) {
+		t.Fatalf("unexpected record: %v", r)
+	}
+}
+
+// TestWebhookHandlerDeadLetter tests that batches which keep failing are written to the dead-letter writer.
+func TestWebhookHandlerDeadLetter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	var deadLetter bytes.Buffer
+	handler := NewWebhookHandler(server.URL, NewWebhookOptions().
+		WithRetry(2, time.Millisecond).
+		WithDeadLetter(&deadLetter))
+	GetBuilder().FromHandler(handler).Error("failed")
+
+	if err := handler.Flush(); err == nil {
+		t.Fatal("Flush() error = nil, want error")
+	}
+	var batch []map[string]any
+	if err := json.Unmarshal(deadLetter.Bytes(), &batch); err != nil || len(batch) != 1 || batch[0]["msg"] != "failed" {
+		t.Fatalf("unexpected dead letter: %s, error = %v", deadLetter.String(), err)
+	}
+	_ = handler.Close()
+}
+
+// TestWebhookHandlerRetryAfter tests that Retry-After is capped by the max backoff and that Close interrupts the wait.
+func TestWebhookHandlerRetryAfter(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		if requests.Add(1)%2 == 1 {
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	capped := NewWebhookHandler(server.URL, NewWebhookOptions().WithRetry(1, time.Millisecond).WithMaxBackoff(10*time.Millisecond))
+	GetBuilder().FromHandler(capped).Info("capped")
+	start := time.Now()
+	if err := capped.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("Retry-After is not capped, Flush() took %s", elapsed)
+	}
+	_ = capped.Close()
+
+	var deadLetter bytes.Buffer
+	interrupted := NewWebhookHandler(server.URL, NewWebhookOptions().WithBatchSize(1).WithRetry(1, time.Millisecond).WithMaxBackoff(0).WithDeadLetter(&deadLetter))
+	GetBuilder().FromHandler(interrupted).Info("interrupted")
+	for requests.Load() < 3 {
+		time.Sleep(time.Millisecond)
+	}
+	start = time.Now()
+	if err := interrupted.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("Close() does not interrupt the retry wait, it took %s", elapsed)
+	}
+	if !bytes.Contains(deadLetter.Bytes(), []byte("interrupted")) {
+		t.Fatalf("interrupted batch is not dead-lettered: %s", deadLetter.String())
+	}
+}
+
+// TestWebhookHandlerFlushDuringClose tests that a Flush waiting behind a retry backoff does not block Close.
+func TestWebhookHandlerFlushDuringClose(t *testing.T) {
+	requested := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		select {
+		case requested <- struct{}{}:
+		default:
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	handler := NewWebhookHandler(server.URL, NewWebhookOptions().WithBatchSize(1).WithRetry(1, time.Hour).WithMaxBackoff(0))
+	GetBuilder().FromHandler(handler).Info("backoff")
+	<-requested
+
+	flushed := make(chan struct{})
+	go func() {
+		defer close(flushed)
+		_ = handler.Flush()
+	}()
+	// give Flush time to block behind the retry wait before closing
+	time.Sleep(50 * time.Millisecond)
+	closed := make(chan error, 1)
+	go func() {
+		closed <- handler.Close()
+	}()
+
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close() blocks behind a pending Flush()")
+	}
+	select {
+	case <-flushed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Flush() blocks after Close()")
+	}
+	if err := handler.Flush(); !errors.Is(err, ErrWebhookClosed) {
+		t.Fatalf("Flush() after Close() error = %v, want ErrWebhookClosed", err)
+	}
+}
+
+// TestWebhookHandlerDropped tests that records are dropped and counted instead of blocking when the queue is full.
+func TestWebhookHandlerDropped(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		<-release
+	}))
+	defer server.Close()
+
+	handler := NewWebhookHandler(server.URL, NewWebhookOptions().WithBatchSize(1).WithQueueSize(1))
+	logger := GetBuilder().FromHandler(handler)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 10; i++ {
+			logger.Info("record", "i", i)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Handle() blocks on a full queue")
+	}
+	if handler.Dropped() == 0 {
+		t.Fatal("Dropped() = 0, want dropped records")
+	}
+
+	close(release)
+	if err := handler.Close(); err != nil && !errors.Is(err, ErrWebhookClosed) {
+		t.Fatalf("Close() error = %v", err)
+	}
+}